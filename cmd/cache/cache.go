@@ -6,6 +6,13 @@
 package cache
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -13,14 +20,86 @@ import (
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "Manage cached scraping results",
-	Long: `This command provides tools for clearing cached scraping results.
+	Long: `This command provides tools for inspecting, summarizing and clearing cached scraping results.
 This helps avoid unnecessary network requests and enables quick access to past data.
-Subcommands include 'clear' to remove entries.`,
+Subcommands include 'list', 'show' and 'stats' to inspect entries, 'prune' and 'clear' to remove them, 'verify' to check them for corruption,
+and 'export' and 'import' to share them as portable archives.`,
 }
 
 // NewCommand returns the cache command for inspecting, clearing,
 // or summarizing cached scraping results.
 func NewCommand() *cobra.Command {
 	cacheCmd.AddCommand(clearCmd)
+	cacheCmd.AddCommand(listCmd)
+	cacheCmd.AddCommand(showCmd)
+	cacheCmd.AddCommand(statsCmd)
+	cacheCmd.AddCommand(pruneCmd)
+	cacheCmd.AddCommand(verifyCmd)
+	cacheCmd.AddCommand(exportCmd)
+	cacheCmd.AddCommand(importCmd)
 	return cacheCmd
 }
+
+// getCache returns the shared cache, opened with the database settings of the
+// config file passed through --config, if any, and the cache location flags.
+func getCache(cmd *cobra.Command) (storage.CacheStorage, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return openCache(cfg)
+}
+
+// loadConfig returns the config file passed through --config, or the defaults,
+// with the cache location flags applied.
+func loadConfig(cmd *cobra.Command) (config.Config, error) {
+	cfg := config.Defaults()
+
+	configFile, _ := cmd.Flags().GetString(flags.FlagConfig)
+	if configFile != "" {
+		var err error
+		cfg, err = config.NewManager(configFile).LoadFromFile(configFile)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(flags.FlagCacheDir) {
+		cfg.Database.Path, _ = cmd.Flags().GetString(flags.FlagCacheDir)
+	}
+	if cmd.Flags().Changed(flags.FlagCacheNamespace) {
+		cfg.Database.Namespace, _ = cmd.Flags().GetString(flags.FlagCacheNamespace)
+	}
+	if cmd.Flags().Changed(flags.FlagCacheReadOnly) {
+		cfg.Database.ReadOnly, _ = cmd.Flags().GetBool(flags.FlagCacheReadOnly)
+	}
+
+	return cfg, nil
+}
+
+// openCache returns the shared cache configured with the database settings of cfg.
+func openCache(cfg config.Config) (storage.CacheStorage, error) {
+	if err := cfg.Database.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	manager := storage.GetCacheManager()
+	manager.Configure(cfg.Database)
+
+	cache, err := manager.GetCache()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache: %w", err)
+	}
+
+	return cache, nil
+}
+
+// writeJSON prints v to stdout as indented JSON.
+func writeJSON(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(b))
+	return err
+}
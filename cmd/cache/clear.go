@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/JesterSe7en/porygo/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -25,10 +24,9 @@ has changed and you need to force an update.
 Example:
   porygo cache clear`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager := storage.GetCacheManager()
-		cache, err := manager.GetCache()
+		cache, err := getCache(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to get cache: %w", err)
+			return err
 		}
 
 		if err := cache.Clear(context.Background()); err != nil {
@@ -0,0 +1,151 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/scraper"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write cache entries to a portable archive.",
+	Long: `The export command streams the entries of the local cache to an NDJSON or tar
+archive, which can be shared with other machines or checked into test fixtures and
+loaded again with 'porygo cache import'.
+
+Every entry is written with its key, creation and expiration time, its metadata (URL,
+status, headers and extracted results) as JSON and the raw response body, so archives
+stay readable and load into later versions of porygo. Entries that cannot be decoded
+are skipped. Without a file, or with '-', the archive is written to stdout. The
+format is taken from the file extension ('.tar' for tar, NDJSON otherwise) unless
+--format is given.
+
+Expired entries are skipped unless --include-expired is set, and --host limits the
+export to URLs whose host matches a glob such as '*.example.com'.
+
+Examples:
+  porygo cache export cache.ndjson
+  porygo cache export --host "*.example.com" fixtures/cache.tar
+  porygo cache export --format tar | gzip > cache.tar.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := newArchiveFilter(cmd)
+		if err != nil {
+			return err
+		}
+
+		name := "-"
+		if len(args) == 1 {
+			name = args[0]
+		}
+		format := archiveFormat(cmd, name)
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if name != "-" {
+			file, err := os.Create(name)
+			if err != nil {
+				return fmt.Errorf("failed to create archive: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		archive, err := storage.NewArchiveWriter(out, format)
+		if err != nil {
+			return err
+		}
+
+		exported, unreadable := 0, 0
+		err = cache.ForEach(context.Background(), func(key string, entry storage.CacheEntry) error {
+			if !filter.matches(entry) {
+				return nil
+			}
+
+			metadata, body, err := scraper.ArchiveValue(entry.Value)
+			if err != nil {
+				unreadable++
+				return nil
+			}
+
+			exported++
+			return archive.Write(storage.NewArchiveEntry(key, entry, metadata, body))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export cache: %w", err)
+		}
+
+		if err := archive.Close(); err != nil {
+			return fmt.Errorf("failed to export cache: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Exported %d entries.\n", exported)
+		if unreadable > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %d entries that could not be decoded.\n", unreadable)
+		}
+		return nil
+	},
+}
+
+// archiveFilter selects the entries copied by export and import.
+type archiveFilter struct {
+	hostPattern    string
+	includeExpired bool
+	now            time.Time
+}
+
+func newArchiveFilter(cmd *cobra.Command) (archiveFilter, error) {
+	hostPattern, _ := cmd.Flags().GetString(flags.FlagHost)
+	includeExpired, _ := cmd.Flags().GetBool(flags.FlagIncludeExpired)
+
+	if hostPattern != "" {
+		if _, err := path.Match(hostPattern, ""); err != nil {
+			return archiveFilter{}, fmt.Errorf("invalid host pattern %q: %w", hostPattern, err)
+		}
+	}
+
+	return archiveFilter{hostPattern: hostPattern, includeExpired: includeExpired, now: time.Now()}, nil
+}
+
+func (f archiveFilter) matches(entry storage.CacheEntry) bool {
+	if !f.includeExpired && f.now.After(entry.ExpirationTime) {
+		return false
+	}
+	return f.hostPattern == "" || matchesHost(entry, f.hostPattern)
+}
+
+// archiveFormat returns the format given with --format, or the one implied by name.
+func archiveFormat(cmd *cobra.Command, name string) string {
+	if cmd.Flags().Changed(flags.FlagFormat) {
+		format, _ := cmd.Flags().GetString(flags.FlagFormat)
+		return format
+	}
+	return storage.ArchiveFormat(name)
+}
+
+// addArchiveFlags registers the flags shared by export and import.
+func addArchiveFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flags.FlagFormat, "", "archive format (ndjson|tar), taken from the file extension by default")
+	cmd.Flags().String(flags.FlagHost, "", "only copy entries whose host matches this glob")
+	cmd.Flags().Bool(flags.FlagIncludeExpired, false, "also copy expired entries")
+}
+
+func init() {
+	addArchiveFlags(exportCmd)
+}
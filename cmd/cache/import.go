@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JesterSe7en/porygo/internal/scraper"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Load cache entries from a portable archive.",
+	Long: `The import command reads an archive written by 'porygo cache export' and stores its
+entries in the local cache, replacing entries with the same key. Use '-' to read the
+archive from stdin.
+
+The format is taken from the file extension ('.tar' for tar, NDJSON otherwise) unless
+--format is given. Expired entries are skipped unless --include-expired is set, and
+--host limits the import to URLs whose host matches a glob.
+
+Examples:
+  porygo cache import cache.ndjson
+  porygo cache import --include-expired fixtures/cache.tar
+  gunzip -c cache.tar.gz | porygo cache import --format tar -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := newArchiveFilter(cmd)
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		format := archiveFormat(cmd, name)
+
+		var in io.Reader = os.Stdin
+		if name != "-" {
+			file, err := os.Open(name)
+			if err != nil {
+				return fmt.Errorf("failed to open archive: %w", err)
+			}
+			defer file.Close()
+			in = file
+		}
+
+		archive, err := storage.NewArchiveReader(in, format)
+		if err != nil {
+			return err
+		}
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		imported, skipped := 0, 0
+		for {
+			archived, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import cache after %d entries: %w", imported, err)
+			}
+
+			value, err := scraper.CacheValue(archived.Metadata, archived.Body)
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", archived.Key, err)
+			}
+
+			entry := archived.CacheEntry(value)
+			if !filter.matches(entry) {
+				skipped++
+				continue
+			}
+
+			if err := cache.Set(ctx, archived.Key, entry); err != nil {
+				return fmt.Errorf("failed to import %s: %w", archived.Key, err)
+			}
+			imported++
+		}
+
+		fmt.Printf("Imported %d entries, skipped %d.\n", imported, skipped)
+		return nil
+	},
+}
+
+func init() {
+	addArchiveFlags(importCmd)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/scraper"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// listItem is a single row printed by the list command.
+type listItem struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+	Size      int       `json:"size"`
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached entries with their expiry and size.",
+	Long: `The list command prints every key stored in the local cache together with the
+URL it belongs to, when it expires and how many bytes it occupies.
+
+Use the printed key with 'porygo cache show' to inspect a single entry.
+
+Examples:
+  porygo cache list
+  porygo cache list --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool(flags.FlagJSON)
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		items := []listItem{}
+		err = cache.ForEach(context.Background(), func(key string, entry storage.CacheEntry) error {
+			item := listItem{
+				Key:       key,
+				ExpiresAt: entry.ExpirationTime,
+				Expired:   now.After(entry.ExpirationTime),
+				Size:      len(entry.Value),
+			}
+			if data, err := scraper.DecodeCacheValue(entry.Value); err == nil {
+				item.URL = data.URL
+			}
+			items = append(items, item)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+
+		if asJSON {
+			return writeJSON(items)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tURL\tEXPIRES\tSIZE")
+		for _, item := range items {
+			expires := item.ExpiresAt.Format(time.RFC3339)
+			if item.Expired {
+				expires += " (expired)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", item.Key, item.URL, expires, item.Size)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().Bool(flags.FlagJSON, false, "print entries as JSON")
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/scraper"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired entries and reclaim disk space.",
+	Long: `The prune command deletes expired entries from the local cache and then compacts
+the database file so the freed space is returned to the filesystem.
+
+Entries can additionally be pruned by age with --older-than and by host with --host,
+which accepts a glob such as '*.example.com'. When both are given an entry must match
+both to be removed. Expired entries are removed unless a scrape could still use them:
+responses kept for revalidation within 'max_stale' and copies that 'stale_if_error'
+may still serve.
+
+Set 'prune_on_startup = true' in the [database] section of the config file to remove
+expired entries automatically before every scrape.
+
+Examples:
+  porygo cache prune
+  porygo cache prune --older-than 168h
+  porygo cache prune --host "*.example.com" --no-compact`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetDuration(flags.FlagOlderThan)
+		hostPattern, _ := cmd.Flags().GetString(flags.FlagHost)
+		noCompact, _ := cmd.Flags().GetBool(flags.FlagNoCompact)
+
+		if hostPattern != "" {
+			if _, err := path.Match(hostPattern, ""); err != nil {
+				return fmt.Errorf("invalid host pattern %q: %w", hostPattern, err)
+			}
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		cache, err := openCache(cfg)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		now := time.Now()
+		filtered := olderThan > 0 || hostPattern != ""
+
+		pruned, err := cache.DeleteFunc(ctx, func(key string, entry storage.CacheEntry) bool {
+			if scraper.Prunable(&cfg, key, entry, now) {
+				return true
+			}
+			if !filtered {
+				return false
+			}
+			if olderThan > 0 && now.Sub(entry.CreatedAt) < olderThan {
+				return false
+			}
+			if hostPattern != "" && !matchesHost(entry, hostPattern) {
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prune cache: %w", err)
+		}
+
+		fmt.Printf("Pruned %d entries.\n", pruned)
+
+		compactor, ok := cache.(storage.Compactor)
+		if noCompact || !ok {
+			return nil
+		}
+
+		before, _ := storage.CollectStats(ctx, cache)
+		if err := compactor.Compact(ctx); err != nil {
+			return fmt.Errorf("failed to compact cache: %w", err)
+		}
+		after, _ := storage.CollectStats(ctx, cache)
+
+		fmt.Printf("Compacted cache from %d to %d bytes.\n", before.FileSize, after.FileSize)
+		return nil
+	},
+}
+
+// matchesHost reports whether the URL cached in entry has a host matching pattern.
+func matchesHost(entry storage.CacheEntry, pattern string) bool {
+	data, err := scraper.DecodeCacheValue(entry.Value)
+	if err != nil {
+		return false
+	}
+
+	u, err := url.Parse(data.URL)
+	if err != nil {
+		return false
+	}
+
+	matched, _ := path.Match(pattern, u.Hostname())
+	return matched
+}
+
+func init() {
+	pruneCmd.Flags().Duration(flags.FlagOlderThan, 0, "also prune entries created longer ago than this duration")
+	pruneCmd.Flags().String(flags.FlagHost, "", "also prune entries whose host matches this glob")
+	pruneCmd.Flags().Bool(flags.FlagNoCompact, false, "skip compacting the database after pruning")
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/presenter"
+	"github.com/JesterSe7en/porygo/internal/scraper"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// showItem is the JSON document printed by the show command.
+type showItem struct {
+	Key       string              `json:"key"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Expired   bool                `json:"expired"`
+	Size      int                 `json:"size"`
+	Data      scraper.ScrapedData `json:"data"`
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show <key>",
+	Short: "Show the decoded contents of a cached entry.",
+	Long: `The show command decodes a single cache entry and prints the scraped data it holds,
+including metadata, extracted values, regex matches and stored response headers.
+
+Keys are printed by 'porygo cache list'.
+
+Examples:
+  porygo cache show result/<request>/<extraction>
+  porygo cache show response/<request> --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool(flags.FlagJSON)
+		key := args[0]
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		entry, err := cache.Get(context.Background(), key)
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("no cache entry with key %s", key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cache entry: %w", err)
+		}
+
+		data, err := scraper.DecodeCacheValue(entry.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+		}
+
+		item := showItem{
+			Key:       key,
+			ExpiresAt: entry.ExpirationTime,
+			Expired:   time.Now().After(entry.ExpirationTime),
+			Size:      len(entry.Value),
+			Data:      data,
+		}
+
+		if asJSON {
+			return writeJSON(item)
+		}
+
+		fmt.Printf("Key:          %s\n", item.Key)
+		fmt.Printf("Expires:      %s", item.ExpiresAt.Format(time.RFC3339))
+		if item.Expired {
+			fmt.Print(" (expired)")
+		}
+		fmt.Printf("\nEntry Size:   %d bytes\n\n", item.Size)
+
+		return presenter.NewTextPresenter(os.Stdout).Write(data)
+	},
+}
+
+func init() {
+	showCmd.Flags().Bool(flags.FlagJSON, false, "print the entry as JSON")
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the contents of the local cache.",
+	Long: `The stats command prints aggregate information about the local cache: the number
+of entries, how many of them have expired, the total size of the stored values, the
+size of the database file on disk and how many entries were evicted to respect the
+max_size and max_entries limits.
+
+Examples:
+  porygo cache stats
+  porygo cache stats --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool(flags.FlagJSON)
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		stats, err := storage.CollectStats(context.Background(), cache)
+		if err != nil {
+			return fmt.Errorf("failed to collect cache stats: %w", err)
+		}
+
+		if asJSON {
+			return writeJSON(stats)
+		}
+
+		fmt.Printf("Entries:      %d\n", stats.Entries)
+		fmt.Printf("Expired:      %d\n", stats.Expired)
+		fmt.Printf("Total Size:   %d bytes\n", stats.TotalBytes)
+		fmt.Printf("File Size:    %d bytes\n", stats.FileSize)
+		fmt.Printf("Evictions:    %d\n", stats.Evictions)
+		return nil
+	},
+}
+
+func init() {
+	statsCmd.Flags().Bool(flags.FlagJSON, false, "print stats as JSON")
+}
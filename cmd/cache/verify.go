@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check every cache entry for corruption.",
+	Long: `The verify command reads the whole cache and checks the checksum of every entry.
+For the bolt backend the structure of the database file is checked as well.
+
+Corrupt entries are never returned to a scrape; they are discarded with a warning the
+next time they are read. Pass --repair to delete all of them at once. The command
+exits with an error while corrupt entries remain.
+
+Examples:
+  porygo cache verify
+  porygo cache verify --repair --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool(flags.FlagJSON)
+		repair, _ := cmd.Flags().GetBool(flags.FlagRepair)
+
+		cache, err := getCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		verifier, ok := cache.(storage.Verifier)
+		if !ok {
+			fmt.Println("This cache backend does not store encoded entries; nothing to verify.")
+			return nil
+		}
+
+		report, err := verifier.Verify(context.Background(), repair)
+		if err != nil {
+			return fmt.Errorf("failed to verify cache: %w", err)
+		}
+
+		if asJSON {
+			if err := writeJSON(report); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Checked %d entries.\n", report.Checked)
+			for _, problem := range report.Problems {
+				fmt.Printf("Database problem: %s\n", problem)
+			}
+			for _, corrupt := range report.Corrupt {
+				fmt.Printf("Corrupt entry %s: %s\n", corrupt.Key, corrupt.Reason)
+			}
+			if report.Removed > 0 {
+				fmt.Printf("Removed %d corrupt entries.\n", report.Removed)
+			}
+		}
+
+		if remaining := len(report.Corrupt) - report.Removed; remaining > 0 {
+			return fmt.Errorf("found %d corrupt entries, run with --%s to remove them", remaining, flags.FlagRepair)
+		}
+		if len(report.Problems) > 0 {
+			return fmt.Errorf("found %d problems in the database file", len(report.Problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().Bool(flags.FlagJSON, false, "print the report as JSON")
+	verifyCmd.Flags().Bool(flags.FlagRepair, false, "delete corrupt entries")
+}
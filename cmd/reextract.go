@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/JesterSe7en/porygo/internal/app"
+	"github.com/JesterSe7en/porygo/internal/flags"
+	"github.com/JesterSe7en/porygo/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// reextractCmd represents the reextract command
+var reextractCmd = &cobra.Command{
+	Use:   "reextract [urls...]",
+	Short: "Re-run selectors and patterns against cached responses",
+	Long: `The reextract command applies CSS selectors and regex patterns to the raw
+responses stored in the local cache, without making any network requests.
+
+Every response previously fetched by porygo is processed, including expired ones.
+Pass one or more URLs to limit the run to those responses. This makes it cheap to
+iterate on selectors: scrape once, then re-extract as often as needed.
+
+Examples:
+  porygo reextract -s "h1" -s "a@href"
+  porygo reextract -p "[0-9]+" https://example.com`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		verbose, _ := cmd.Flags().GetBool(flags.FlagVerbose)
+		filename, _ := cmd.Flags().GetString(flags.FlagLog)
+		debug, _ := cmd.Flags().GetBool(flags.FlagDebug)
+
+		log, err := logger.New(filename, debug, verbose)
+		if err != nil {
+			return err
+		}
+		defer log.Sync()
+
+		cfg, err := setupConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		app, err := app.New(&log, &cfg)
+		if err != nil {
+			return err
+		}
+
+		return app.Reextract(ctx, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reextractCmd)
+
+	reextractCmd.Flags().StringSliceP(flags.FlagSelect, "s", []string{}, "CSS selectors to extract")
+	reextractCmd.Flags().StringSliceP(flags.FlagPattern, "p", []string{}, "regex patterns to match")
+	reextractCmd.Flags().StringP(flags.FlagFormat, "o", "json", "output format (json|plain)")
+	reextractCmd.Flags().BoolP(flags.FlagQuiet, "q", false, "only output extracted data")
+	reextractCmd.Flags().BoolP(flags.FlagHeaders, "H", false, "include response headers")
+}
@@ -8,10 +8,12 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/url"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 
 	cacheCmd "github.com/JesterSe7en/porygo/cmd/cache"
@@ -21,6 +23,7 @@ import (
 	"github.com/JesterSe7en/porygo/internal/app"
 	"github.com/JesterSe7en/porygo/internal/flags"
 	"github.com/JesterSe7en/porygo/internal/logger"
+	"github.com/JesterSe7en/porygo/internal/scraper"
 	"github.com/spf13/cobra"
 )
 
@@ -54,12 +57,12 @@ Output can be saved in JSON or CSV format, and verbose logging is available for
 			return err
 		}
 
-		urls, err := getURLs(args)
+		targets, err := getTargets(args)
 		if err != nil {
 			return err
 		}
 
-		if len(urls) == 0 {
+		if len(targets) == 0 {
 			return cmd.Help()
 		}
 
@@ -68,7 +71,7 @@ Output can be saved in JSON or CSV format, and verbose logging is available for
 			return err
 		}
 
-		return app.Run(ctx, urls)
+		return app.Run(ctx, targets)
 	},
 }
 
@@ -96,12 +99,17 @@ func init() {
 	rootCmd.PersistentFlags().BoolP(flags.FlagVerbose, "v", false, "show logs for each step")
 	// config and Concurrency cannot use same shorthand character
 	rootCmd.PersistentFlags().String(flags.FlagConfig, "", "specify config file")
+	rootCmd.PersistentFlags().String(flags.FlagCacheDir, "", "directory holding the cache (default is the user cache directory)")
+	rootCmd.PersistentFlags().String(flags.FlagCacheNamespace, "", "keep cache entries separate from other projects under this name")
+	rootCmd.PersistentFlags().Bool(flags.FlagCacheReadOnly, false, "read from the cache without ever writing to it")
 	rootCmd.Flags().IntP(flags.FlagConcurrency, "c", defaults.Concurrency, "number of workers")
 	rootCmd.Flags().DurationP(flags.FlagTimeout, "t", defaults.Timeout, "request timeout per URL")
 	rootCmd.Flags().IntP(flags.FlagRetry, "r", defaults.Retry, "number of retries per URL on failure")
 	rootCmd.Flags().Duration(flags.FlagRetryDelay, defaults.Backoff.BaseDelay, "base delay between retries (exponential backoff applied)")
 	rootCmd.Flags().Bool(flags.FlagRetryJitter, defaults.Backoff.Jitter, "enable jitter for retry delays")
 	rootCmd.Flags().BoolP(flags.FlagForce, "f", defaults.Force, "ignore cache and scrape fresh data")
+	rootCmd.Flags().Bool(flags.FlagOffline, defaults.Offline, "serve only from the cache and fail on misses")
+	rootCmd.Flags().Duration(flags.FlagStaleIfError, defaults.StaleIfError, "serve a cached copy expired up to this long ago when scraping fails")
 
 	// scraper flags
 	rootCmd.Flags().StringSliceP(flags.FlagSelect, "s", []string{}, "CSS selectors to extract")
@@ -110,6 +118,26 @@ func init() {
 	rootCmd.Flags().BoolP(flags.FlagQuiet, "q", false, "only output extracted data")
 	rootCmd.Flags().BoolP(flags.FlagHeaders, "H", false, "include response headers")
 
+	// request flags
+	rootCmd.Flags().StringArray(flags.FlagHeader, []string{}, "extra request header as 'Name: value' (repeatable)")
+	rootCmd.Flags().StringArray(flags.FlagCookie, []string{}, "cookie to send as name=value (repeatable)")
+	rootCmd.Flags().String(flags.FlagUserAgent, "", "User-Agent header to send")
+	rootCmd.Flags().StringP(flags.FlagMethod, "X", "", "HTTP method (default GET, or POST with a body)")
+	rootCmd.Flags().String(flags.FlagData, "", "request body to send")
+	rootCmd.Flags().String(flags.FlagDataFile, "", "file holding the request body to send")
+	rootCmd.Flags().String(flags.FlagJSONBody, "", "JSON request body to send with Content-Type application/json")
+	rootCmd.MarkFlagsMutuallyExclusive(flags.FlagData, flags.FlagDataFile, flags.FlagJSONBody)
+	rootCmd.Flags().Bool(flags.FlagNetrc, false, "send credentials from ~/.netrc (or $NETRC) to matching hosts")
+	rootCmd.Flags().String(flags.FlagNetrcFile, "", "send credentials from this netrc file to matching hosts")
+	rootCmd.Flags().StringArray(flags.FlagProxy, nil, "proxy URL (http, https, socks5) to route requests through, rotated when repeated")
+	rootCmd.Flags().Float64(flags.FlagRateLimit, 0, "requests per second sent to each host (0 = unlimited)")
+	rootCmd.Flags().Duration(flags.FlagDelay, 0, "minimum delay between requests to the same host")
+	rootCmd.Flags().Int(flags.FlagPerHost, 0, "requests in flight per host (0 = unlimited)")
+	rootCmd.Flags().Bool(flags.FlagIgnoreRobots, false, "fetch URLs disallowed by robots.txt and ignore Crawl-delay")
+	rootCmd.Flags().Bool(flags.FlagInsecure, false, "skip TLS certificate verification (unsafe)")
+	rootCmd.Flags().Bool(flags.FlagSession, false, "keep cookies set by responses for later requests")
+	rootCmd.Flags().String(flags.FlagCookieJar, "", "load session cookies from and save them to this cookies.txt file")
+
 }
 
 func setupConfig(cmd *cobra.Command) (config.Config, error) {
@@ -133,7 +161,10 @@ func setupConfig(cmd *cobra.Command) (config.Config, error) {
 
 	// Flags manually set takes precedence over whatever config file says
 	// Override with CLI flags if provided
-	cfg = mergeCLIFlags(cmd, cfg)
+	cfg, err = mergeCLIFlags(cmd, cfg)
+	if err != nil {
+		return config.Config{}, err
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -144,7 +175,7 @@ func setupConfig(cmd *cobra.Command) (config.Config, error) {
 }
 
 // mergeCLIFlags merges CLI flag values into the configuration
-func mergeCLIFlags(cmd *cobra.Command, cfg config.Config) config.Config {
+func mergeCLIFlags(cmd *cobra.Command, cfg config.Config) (config.Config, error) {
 	if cmd.Flags().Changed(flags.FlagConcurrency) {
 		cfg.Concurrency, _ = cmd.Flags().GetInt(flags.FlagConcurrency)
 	}
@@ -163,6 +194,23 @@ func mergeCLIFlags(cmd *cobra.Command, cfg config.Config) config.Config {
 	if cmd.Flags().Changed(flags.FlagForce) {
 		cfg.Force, _ = cmd.Flags().GetBool(flags.FlagForce)
 	}
+	if cmd.Flags().Changed(flags.FlagOffline) {
+		cfg.Offline, _ = cmd.Flags().GetBool(flags.FlagOffline)
+	}
+	if cmd.Flags().Changed(flags.FlagStaleIfError) {
+		cfg.StaleIfError, _ = cmd.Flags().GetDuration(flags.FlagStaleIfError)
+	}
+
+	// cache location flags
+	if cmd.Flags().Changed(flags.FlagCacheDir) {
+		cfg.Database.Path, _ = cmd.Flags().GetString(flags.FlagCacheDir)
+	}
+	if cmd.Flags().Changed(flags.FlagCacheNamespace) {
+		cfg.Database.Namespace, _ = cmd.Flags().GetString(flags.FlagCacheNamespace)
+	}
+	if cmd.Flags().Changed(flags.FlagCacheReadOnly) {
+		cfg.Database.ReadOnly, _ = cmd.Flags().GetBool(flags.FlagCacheReadOnly)
+	}
 
 	// scraper flags
 	if cmd.Flags().Changed(flags.FlagSelect) {
@@ -182,10 +230,92 @@ func mergeCLIFlags(cmd *cobra.Command, cfg config.Config) config.Config {
 		cfg.Headers, _ = cmd.Flags().GetBool(flags.FlagHeaders)
 	}
 
-	return cfg
+	// request flags
+	if cmd.Flags().Changed(flags.FlagHeader) {
+		headers, _ := cmd.Flags().GetStringArray(flags.FlagHeader)
+		merged := make(map[string]string, len(cfg.Request.Headers)+len(headers))
+		maps.Copy(merged, cfg.Request.Headers)
+		for _, header := range headers {
+			name, value, ok := strings.Cut(header, ":")
+			if !ok {
+				return config.Config{}, fmt.Errorf("invalid header %q, expected 'Name: value'", header)
+			}
+			merged[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		cfg.Request.Headers = merged
+	}
+	if cmd.Flags().Changed(flags.FlagCookie) {
+		cookies, _ := cmd.Flags().GetStringArray(flags.FlagCookie)
+		cfg.Request.Cookies = append(slices.Clone(cfg.Request.Cookies), cookies...)
+	}
+	if cmd.Flags().Changed(flags.FlagUserAgent) {
+		cfg.Request.UserAgent, _ = cmd.Flags().GetString(flags.FlagUserAgent)
+	}
+	if cmd.Flags().Changed(flags.FlagMethod) {
+		cfg.Request.Method, _ = cmd.Flags().GetString(flags.FlagMethod)
+	}
+	if cmd.Flags().Changed(flags.FlagData) {
+		cfg.Request.Body, _ = cmd.Flags().GetString(flags.FlagData)
+	}
+	if cmd.Flags().Changed(flags.FlagDataFile) {
+		dataFile, _ := cmd.Flags().GetString(flags.FlagDataFile)
+		data, err := os.ReadFile(dataFile)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("failed to read request body: %w", err)
+		}
+		cfg.Request.Body = string(data)
+	}
+	if cmd.Flags().Changed(flags.FlagNetrc) {
+		cfg.Request.Netrc, _ = cmd.Flags().GetBool(flags.FlagNetrc)
+	}
+	if cmd.Flags().Changed(flags.FlagNetrcFile) {
+		cfg.Request.NetrcFile, _ = cmd.Flags().GetString(flags.FlagNetrcFile)
+		cfg.Request.Netrc = true
+	}
+	if cmd.Flags().Changed(flags.FlagProxy) {
+		cfg.Proxy.URLs, _ = cmd.Flags().GetStringArray(flags.FlagProxy)
+	}
+	if cmd.Flags().Changed(flags.FlagRateLimit) {
+		cfg.RateLimit.RequestsPerSecond, _ = cmd.Flags().GetFloat64(flags.FlagRateLimit)
+	}
+	if cmd.Flags().Changed(flags.FlagDelay) {
+		cfg.RateLimit.MinDelay, _ = cmd.Flags().GetDuration(flags.FlagDelay)
+	}
+	if cmd.Flags().Changed(flags.FlagPerHost) {
+		cfg.RateLimit.MaxConcurrent, _ = cmd.Flags().GetInt(flags.FlagPerHost)
+	}
+	if cmd.Flags().Changed(flags.FlagIgnoreRobots) {
+		ignore, _ := cmd.Flags().GetBool(flags.FlagIgnoreRobots)
+		cfg.Robots.Enabled = !ignore
+	}
+	if cmd.Flags().Changed(flags.FlagInsecure) {
+		cfg.TLS.Insecure, _ = cmd.Flags().GetBool(flags.FlagInsecure)
+	}
+	if cmd.Flags().Changed(flags.FlagSession) {
+		cfg.Session.Cookies, _ = cmd.Flags().GetBool(flags.FlagSession)
+	}
+	if cmd.Flags().Changed(flags.FlagCookieJar) {
+		cfg.Session.CookieFile, _ = cmd.Flags().GetString(flags.FlagCookieJar)
+	}
+	if cmd.Flags().Changed(flags.FlagJSONBody) {
+		body, _ := cmd.Flags().GetString(flags.FlagJSONBody)
+		if !json.Valid([]byte(body)) {
+			return config.Config{}, fmt.Errorf("invalid JSON request body: %s", body)
+		}
+		cfg.Request.Body = body
+		cfg.Request.Headers = maps.Clone(cfg.Request.Headers)
+		if cfg.Request.Headers == nil {
+			cfg.Request.Headers = make(map[string]string)
+		}
+		cfg.Request.Headers["Content-Type"] = "application/json"
+	}
+
+	return cfg, nil
 }
 
-func getURLs(args []string) ([]string, error) {
+// getTargets reads the targets to scrape from stdin, one per line, or from args.
+// A line is either a URL or a JSON object with per-URL request overrides.
+func getTargets(args []string) ([]scraper.Target, error) {
 	fi, err := os.Stdin.Stat()
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat stdin: %s", err.Error())
@@ -193,41 +323,38 @@ func getURLs(args []string) ([]string, error) {
 
 	// Check for stdin first
 	if (fi.Mode() & os.ModeCharDevice) == 0 {
-		urls := []string{}
+		lines := []string{}
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
-			urls = append(urls, scanner.Text())
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				lines = append(lines, line)
+			}
 		}
 		if err := scanner.Err(); err != nil {
 			return nil, fmt.Errorf("error reading stdin: %v", err)
 		}
-		if len(urls) > 0 {
-			err := validateURLs(urls)
-			if err != nil {
-				return nil, err
-			}
-			return urls, nil
+		if len(lines) > 0 {
+			return parseTargets(lines)
 		}
 	}
 
 	// If no stdin, use args
 	if len(args) > 0 {
-		err := validateURLs(args)
-		if err != nil {
-			return nil, err
-		}
-		return args, nil
+		return parseTargets(args)
 	}
 
 	// No input from stdin or args
-	return []string{}, nil
+	return []scraper.Target{}, nil
 }
 
-func validateURLs(inputs []string) error {
+func parseTargets(inputs []string) ([]scraper.Target, error) {
+	targets := make([]scraper.Target, 0, len(inputs))
 	for _, input := range inputs {
-		if _, err := url.Parse(input); err != nil {
-			return fmt.Errorf("invalid URL: %s", input)
+		target, err := scraper.ParseTarget(input)
+		if err != nil {
+			return nil, err
 		}
+		targets = append(targets, target)
 	}
-	return nil
+	return targets, nil
 }
@@ -6,9 +6,12 @@ package config
 
 import (
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -22,13 +25,152 @@ const (
 )
 
 type Database struct {
-	Expiration time.Duration `toml:"expiration"`
+	Backend        string                   `toml:"backend"`          // cache backend (bolt|memory|fs|none)
+	Expiration     time.Duration            `toml:"expiration"`       // how long cached entries stay valid
+	MaxStale       time.Duration            `toml:"max_stale"`        // how long past expiry a response with validators is kept for revalidation, 0 to drop it on expiry
+	PruneOnStartup bool                     `toml:"prune_on_startup"` // remove expired entries before scraping
+	MaxSize        int64                    `toml:"max_size"`         // maximum total size of cached entries in bytes, 0 for unlimited
+	MaxEntries     int                      `toml:"max_entries"`      // maximum number of cached entries, 0 for unlimited
+	Eviction       string                   `toml:"eviction"`         // eviction policy when a limit is exceeded (lru|lfu)
+	Path           string                   `toml:"path"`             // cache directory, empty for the platform default
+	Namespace      string                   `toml:"namespace"`        // isolates entries of one project from others sharing the cache
+	ReadOnly       bool                     `toml:"read_only"`        // never write to the cache
+	LockTimeout    time.Duration            `toml:"lock_timeout"`     // how long to wait for another process holding the cache
+	TTLMode        string                   `toml:"ttl_mode"`         // how entry lifetimes are chosen (fixed|http)
+	MinTTL         time.Duration            `toml:"min_ttl"`          // lower bound for lifetimes taken from response headers, 0 for none
+	MaxTTL         time.Duration            `toml:"max_ttl"`          // upper bound for lifetimes taken from response headers, 0 for none
+	HostTTL        map[string]time.Duration `toml:"host_ttl"`         // lifetimes for hosts matching a glob, overriding everything else
 }
 
 // BackoffConfig defines exponential backoff configuration
 type BackoffConfig struct {
-	BaseDelay time.Duration `toml:"base_delay"` // Initial delay between retries
-	Jitter    bool          `toml:"jitter"`     // Whether to add jitter (default: true)
+	BaseDelay     time.Duration `toml:"base_delay"`      // Initial delay between retries
+	Jitter        bool          `toml:"jitter"`          // Whether to add jitter (default: true)
+	RetryStatuses []int         `toml:"retry_statuses"`  // HTTP statuses worth retrying; other 4xx and 5xx fail at once
+	MaxRetryAfter time.Duration `toml:"max_retry_after"` // cap on the wait asked for by Retry-After, 0 to ignore Retry-After
+	RetryBudget   int           `toml:"retry_budget"`    // retries shared by every URL of a run, 0 for unlimited
+}
+
+// CircuitBreakerConfig stops sending requests to hosts that keep failing
+type CircuitBreakerConfig struct {
+	Threshold int           `toml:"threshold"` // consecutive failures that open a host's circuit, 0 to disable
+	Cooldown  time.Duration `toml:"cooldown"`  // how long an open circuit fails fast before a probe request is let through
+}
+
+// RequestConfig customizes the requests sent by the scraper
+type RequestConfig struct {
+	Method    string                       `toml:"method"`     // HTTP method, empty for GET or POST when a body is set
+	Body      string                       `toml:"body"`       // request body sent with every request
+	UserAgent string                       `toml:"user_agent"` // User-Agent header, empty for the built-in default
+	Headers   map[string]string            `toml:"headers"`    // headers sent with every request
+	Cookies   []string                     `toml:"cookies"`    // cookies sent with every request, as name=value
+	Hosts     map[string]HostRequestConfig `toml:"hosts"`      // overrides for hosts matching a glob
+	Auth      map[string]AuthConfig        `toml:"auth"`       // credentials for hosts matching a glob
+	Netrc     bool                         `toml:"netrc"`      // look up credentials in the netrc file
+	NetrcFile string                       `toml:"netrc_file"` // netrc file, empty for $NETRC or ~/.netrc
+}
+
+// AuthConfig describes the credentials sent to matching hosts. Secrets are read
+// from environment variables so they never appear in the config or on the command line.
+type AuthConfig struct {
+	Type        string `toml:"type"`         // basic|bearer
+	Username    string `toml:"username"`     // basic auth user name
+	PasswordEnv string `toml:"password_env"` // environment variable holding the basic auth password
+	TokenEnv    string `toml:"token_env"`    // environment variable holding the bearer token
+}
+
+// HostRequestConfig customizes the requests sent to matching hosts
+type HostRequestConfig struct {
+	Headers map[string]string `toml:"headers"` // headers replacing or adding to the global ones
+}
+
+// ProxyConfig routes requests through HTTP, HTTPS or SOCKS5 proxies. Without
+// any proxies configured, HTTP_PROXY, HTTPS_PROXY and NO_PROXY are honored.
+type ProxyConfig struct {
+	URLs        []string          `toml:"urls"`         // proxy pool, rotated between requests
+	Rotation    string            `toml:"rotation"`     // how the pool is rotated (round-robin|random)
+	MaxFailures int               `toml:"max_failures"` // consecutive failures before a proxy is ejected, 0 to never eject
+	EjectFor    time.Duration     `toml:"eject_for"`    // how long an ejected proxy is left out of the rotation
+	Hosts       map[string]string `toml:"hosts"`        // proxy for hosts matching a glob, or "direct" to bypass proxies
+}
+
+// ProxyDirect routes matching hosts around every proxy.
+const ProxyDirect = "direct"
+
+// RobotsConfig controls how robots.txt files are obeyed
+type RobotsConfig struct {
+	Enabled    bool          `toml:"enabled"`    // skip URLs disallowed by robots.txt and honor Crawl-delay
+	UserAgent  string        `toml:"user_agent"` // product token matched against User-agent lines
+	Expiration time.Duration `toml:"expiration"` // how long a fetched robots.txt is cached
+}
+
+// RateLimit throttles the requests sent to a single host
+type RateLimit struct {
+	RequestsPerSecond float64       `toml:"requests_per_second"` // sustained request rate, 0 for unlimited
+	Burst             int           `toml:"burst"`               // requests that may be sent at once before the rate applies
+	MinDelay          time.Duration `toml:"min_delay"`           // minimum time between the starts of two requests
+	MaxConcurrent     int           `toml:"max_concurrent"`      // requests in flight at once, 0 for unlimited
+}
+
+// RateLimitConfig holds the limits applied to every host and overrides for
+// hosts matching a glob. Each host is throttled on its own, even when several
+// hosts share a glob.
+type RateLimitConfig struct {
+	RateLimit
+	Hosts map[string]RateLimit `toml:"hosts"` // limits replacing the default ones for matching hosts
+}
+
+// TransportConfig tunes the connection pool shared by every request of a run.
+// Connection limits left at 0 are derived from the concurrency.
+type TransportConfig struct {
+	MaxIdleConns          int           `toml:"max_idle_conns"`          // idle connections kept across all hosts, 0 to derive
+	MaxIdleConnsPerHost   int           `toml:"max_idle_conns_per_host"` // idle connections kept per host, 0 to derive
+	MaxConnsPerHost       int           `toml:"max_conns_per_host"`      // connections open per host, 0 for unlimited
+	IdleConnTimeout       time.Duration `toml:"idle_conn_timeout"`       // how long idle connections are kept
+	KeepAlive             time.Duration `toml:"keep_alive"`              // TCP keep-alive interval
+	DialTimeout           time.Duration `toml:"dial_timeout"`            // how long connecting may take
+	TLSHandshakeTimeout   time.Duration `toml:"tls_handshake_timeout"`   // how long the TLS handshake may take
+	ResponseHeaderTimeout time.Duration `toml:"response_header_timeout"` // how long to wait for response headers, 0 for no limit
+	HTTP2                 bool          `toml:"http2"`                   // negotiate HTTP/2 with servers supporting it
+}
+
+// TLSConfig customizes how server certificates are verified and which client
+// certificate is presented.
+type TLSConfig struct {
+	CAFile     string `toml:"ca_file"`     // PEM bundle of CAs trusted in addition to the system ones
+	CertFile   string `toml:"cert_file"`   // PEM client certificate for mutual TLS
+	KeyFile    string `toml:"key_file"`    // PEM private key of the client certificate
+	MinVersion string `toml:"min_version"` // oldest accepted TLS version (1.0|1.1|1.2|1.3), empty for the Go default
+	Insecure   bool   `toml:"insecure"`    // skip server certificate verification
+}
+
+// TLSVersions maps accepted min_version values to crypto/tls version numbers.
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// SessionConfig keeps cookies set by responses across requests and runs
+type SessionConfig struct {
+	Cookies    bool        `toml:"cookies"`     // keep cookies set by responses for later requests
+	CookieFile string      `toml:"cookie_file"` // Netscape cookies.txt loaded before and saved after a run
+	Login      LoginConfig `toml:"login"`       // form submitted before any URL is scraped
+}
+
+// Enabled reports whether a cookie jar is needed. A cookie file or login implies one.
+func (s SessionConfig) Enabled() bool {
+	return s.Cookies || s.CookieFile != "" || s.Login.URL != ""
+}
+
+// LoginConfig describes a form submission that establishes a session. Secret
+// fields are read from environment variables so they stay out of the config.
+type LoginConfig struct {
+	URL     string            `toml:"url"`      // form target, empty to skip the login
+	Method  string            `toml:"method"`   // HTTP method, empty for POST
+	Form    map[string]string `toml:"form"`     // form fields
+	FormEnv map[string]string `toml:"form_env"` // form fields read from the named environment variables
 }
 
 type SelectorsConfig struct {
@@ -38,16 +180,26 @@ type SelectorsConfig struct {
 
 // Config holds all configuration options for the porygo tool
 type Config struct {
-	Concurrency     int             `toml:"concurrency"` // number of concurrent requests
-	Timeout         time.Duration   `toml:"timeout"`     // timeout for each request
-	Format          string          `toml:"format"`      // output format for the scraped data
-	Retry           int             `toml:"retry"`       // number of retries for failed requests
-	Backoff         BackoffConfig   `toml:"backoff"`     // exponential backoff configuration
-	SelectorsConfig SelectorsConfig `toml:"selectors"`   // css/regex selectors configuration
-	Database        Database        `toml:"database"`    // database configuration
-	Force           bool            `toml:"force"`       // force scraping even if data exists
-	Quiet           bool            `toml:"quiet"`       // suppress output, only show scrapped data
-	Headers         bool            `toml:"headers"`     // include headers in output
+	Concurrency     int                  `toml:"concurrency"`     // number of concurrent requests
+	Timeout         time.Duration        `toml:"timeout"`         // timeout for each request
+	Format          string               `toml:"format"`          // output format for the scraped data
+	Retry           int                  `toml:"retry"`           // number of retries for failed requests
+	Backoff         BackoffConfig        `toml:"backoff"`         // exponential backoff configuration
+	CircuitBreaker  CircuitBreakerConfig `toml:"circuit_breaker"` // per-host fail-fast after repeated failures
+	SelectorsConfig SelectorsConfig      `toml:"selectors"`       // css/regex selectors configuration
+	Request         RequestConfig        `toml:"request"`         // request headers and cookies
+	Session         SessionConfig        `toml:"session"`         // cookie jar and login
+	Proxy           ProxyConfig          `toml:"proxy"`           // proxies requests are routed through
+	TLS             TLSConfig            `toml:"tls"`             // certificate verification and client certificates
+	Transport       TransportConfig      `toml:"transport"`       // connection pooling and timeouts
+	RateLimit       RateLimitConfig      `toml:"rate_limit"`      // per-host request throttling
+	Robots          RobotsConfig         `toml:"robots"`          // robots.txt compliance
+	Database        Database             `toml:"database"`        // database configuration
+	Force           bool                 `toml:"force"`           // force scraping even if data exists
+	Offline         bool                 `toml:"offline"`         // serve only from the cache, never touching the network
+	StaleIfError    time.Duration        `toml:"stale_if_error"`  // how long past expiry a cached copy may be served when scraping fails, 0 to disable
+	Quiet           bool                 `toml:"quiet"`           // suppress output, only show scrapped data
+	Headers         bool                 `toml:"headers"`         // include headers in output
 }
 
 type Manager struct {
@@ -71,8 +223,14 @@ func Defaults() Config {
 		Format:      "json",
 		Retry:       3,
 		Backoff: BackoffConfig{
-			BaseDelay: 1 * time.Second,
-			Jitter:    true,
+			BaseDelay:     1 * time.Second,
+			Jitter:        true,
+			RetryStatuses: []int{408, 425, 429, 500, 502, 503, 504},
+			MaxRetryAfter: 1 * time.Minute,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Threshold: 5,
+			Cooldown:  30 * time.Second,
 		},
 		Quiet:   false,
 		Headers: false,
@@ -81,9 +239,31 @@ func Defaults() Config {
 			Pattern: []string{},
 		},
 		Force: false,
-		Database: Database{
+		Proxy: ProxyConfig{
+			Rotation:    "round-robin",
+			MaxFailures: 3,
+			EjectFor:    5 * time.Minute,
+		},
+		Robots: RobotsConfig{
+			Enabled:    true,
+			UserAgent:  "porygo",
 			Expiration: 24 * time.Hour,
 		},
+		Transport: TransportConfig{
+			IdleConnTimeout:     90 * time.Second,
+			KeepAlive:           30 * time.Second,
+			DialTimeout:         30 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			HTTP2:               true,
+		},
+		Database: Database{
+			Backend:     "bolt",
+			Expiration:  24 * time.Hour,
+			MaxStale:    7 * 24 * time.Hour,
+			Eviction:    "lru",
+			LockTimeout: 1 * time.Second,
+			TTLMode:     "fixed",
+		},
 	}
 }
 
@@ -174,6 +354,56 @@ func (cfg *Config) Validate() error {
 		errs = append(errs, "backoff base_delay must be greater than 0")
 	}
 
+	for _, status := range cfg.Backoff.RetryStatuses {
+		if status < 400 || status > 599 {
+			errs = append(errs, fmt.Sprintf("backoff retry_statuses must be 4xx or 5xx codes, got %d", status))
+		}
+	}
+
+	if cfg.Backoff.MaxRetryAfter < 0 {
+		errs = append(errs, "backoff max_retry_after cannot be negative")
+	}
+
+	if cfg.Backoff.RetryBudget < 0 {
+		errs = append(errs, "backoff retry_budget cannot be negative")
+	}
+
+	if cfg.CircuitBreaker.Threshold < 0 || cfg.CircuitBreaker.Cooldown < 0 {
+		errs = append(errs, "circuit_breaker threshold and cooldown cannot be negative")
+	}
+
+	if cfg.Offline && cfg.Force {
+		errs = append(errs, "offline and force cannot be used together")
+	}
+
+	if cfg.StaleIfError < 0 {
+		errs = append(errs, "stale_if_error cannot be negative")
+	}
+
+	errs = append(errs, cfg.Database.problems()...)
+	errs = append(errs, cfg.Request.problems()...)
+	errs = append(errs, cfg.Session.problems()...)
+	errs = append(errs, cfg.Proxy.problems()...)
+
+	if _, ok := TLSVersions[cfg.TLS.MinVersion]; cfg.TLS.MinVersion != "" && !ok {
+		errs = append(errs, "tls min_version must be one of '1.0', '1.1', '1.2' or '1.3'")
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, "tls cert_file and key_file must be set together")
+	}
+
+	errs = append(errs, cfg.Transport.problems()...)
+	errs = append(errs, cfg.RateLimit.problems()...)
+
+	if cfg.Robots.Enabled && !validHeaderName(cfg.Robots.UserAgent) {
+		errs = append(errs, "robots user_agent must be a single product token such as 'porygo'")
+	}
+
+	if cfg.Robots.Expiration < 0 {
+		errs = append(errs, "robots expiration cannot be negative")
+	}
+
 	if len(errs) > 0 {
 		return errors.New("configuration validation failed: " + strings.Join(errs, ", "))
 	}
@@ -181,6 +411,279 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
+// Validate checks if the database settings are valid. It is used by commands
+// that only need the cache and not a complete scraping configuration.
+func (db *Database) Validate() error {
+	if errs := db.problems(); len(errs) > 0 {
+		return errors.New("configuration validation failed: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// problems lists every invalid database setting.
+func (db *Database) problems() []string {
+	var errs []string
+
+	if db.MaxSize < 0 {
+		errs = append(errs, "database max_size cannot be negative")
+	}
+
+	if db.MaxEntries < 0 {
+		errs = append(errs, "database max_entries cannot be negative")
+	}
+
+	if db.MaxStale < 0 {
+		errs = append(errs, "database max_stale cannot be negative")
+	}
+
+	switch backend := strings.ToLower(db.Backend); backend {
+	case "", "bolt", "none":
+	case "memory", "fs":
+		if db.MaxSize > 0 || db.MaxEntries > 0 {
+			errs = append(errs, fmt.Sprintf("database max_size and max_entries are only supported by the 'bolt' backend, not %q", backend))
+		}
+		// A read-only memory cache would stay empty; use the 'none' backend instead.
+		if backend == "memory" && db.ReadOnly {
+			errs = append(errs, "database read_only is not supported by the 'memory' backend")
+		}
+	default:
+		errs = append(errs, "database backend must be one of 'bolt', 'memory', 'fs' or 'none'")
+	}
+
+	eviction := strings.ToLower(db.Eviction)
+	if eviction != "" && eviction != "lru" && eviction != "lfu" {
+		errs = append(errs, "database eviction must be either 'lru' or 'lfu'")
+	}
+
+	if !validNamespace(db.Namespace) {
+		errs = append(errs, "database namespace may only contain letters, digits, '.', '_' and '-'")
+	}
+
+	if db.LockTimeout < 0 {
+		errs = append(errs, "database lock_timeout cannot be negative")
+	}
+
+	ttlMode := strings.ToLower(db.TTLMode)
+	if ttlMode != "" && ttlMode != "fixed" && ttlMode != "http" {
+		errs = append(errs, "database ttl_mode must be either 'fixed' or 'http'")
+	}
+
+	if db.MinTTL < 0 || db.MaxTTL < 0 {
+		errs = append(errs, "database min_ttl and max_ttl cannot be negative")
+	} else if db.MaxTTL > 0 && db.MinTTL > db.MaxTTL {
+		errs = append(errs, "database min_ttl cannot be greater than max_ttl")
+	}
+
+	for pattern, ttl := range db.HostTTL {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("database host_ttl pattern %q is invalid", pattern))
+		}
+		if ttl < 0 {
+			errs = append(errs, fmt.Sprintf("database host_ttl for %q cannot be negative", pattern))
+		}
+	}
+
+	return errs
+}
+
+// problems lists every invalid request setting.
+func (r *RequestConfig) problems() []string {
+	var errs []string
+
+	if r.Method != "" && !validHeaderName(r.Method) {
+		errs = append(errs, fmt.Sprintf("request method %q is invalid", r.Method))
+	}
+
+	for name := range r.Headers {
+		if !validHeaderName(name) {
+			errs = append(errs, fmt.Sprintf("request header name %q is invalid", name))
+		}
+	}
+
+	for _, cookie := range r.Cookies {
+		if name, _, ok := strings.Cut(cookie, "="); !ok || !validHeaderName(name) {
+			errs = append(errs, fmt.Sprintf("request cookie %q must have the form name=value", cookie))
+		}
+	}
+
+	for pattern, auth := range r.Auth {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("request auth host pattern %q is invalid", pattern))
+		}
+		switch strings.ToLower(auth.Type) {
+		case "basic":
+			if auth.Username == "" || auth.PasswordEnv == "" {
+				errs = append(errs, fmt.Sprintf("request basic auth for %q needs username and password_env", pattern))
+			}
+		case "bearer":
+			if auth.TokenEnv == "" {
+				errs = append(errs, fmt.Sprintf("request bearer auth for %q needs token_env", pattern))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("request auth type for %q must be either 'basic' or 'bearer'", pattern))
+		}
+	}
+
+	for pattern, host := range r.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("request host pattern %q is invalid", pattern))
+		}
+		for name := range host.Headers {
+			if !validHeaderName(name) {
+				errs = append(errs, fmt.Sprintf("request header name %q for %q is invalid", name, pattern))
+			}
+		}
+	}
+
+	return errs
+}
+
+// problems lists every invalid rate limit setting.
+func (r *RateLimitConfig) problems() []string {
+	errs := r.RateLimit.problems("rate_limit")
+
+	for pattern, limit := range r.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("rate_limit host pattern %q is invalid", pattern))
+		}
+		errs = append(errs, limit.problems(fmt.Sprintf("rate_limit for %q", pattern))...)
+	}
+
+	return errs
+}
+
+// problems lists every invalid setting of a single limit, described by name.
+func (r RateLimit) problems(name string) []string {
+	var errs []string
+
+	if r.RequestsPerSecond < 0 || r.Burst < 0 || r.MinDelay < 0 || r.MaxConcurrent < 0 {
+		errs = append(errs, fmt.Sprintf("%s values cannot be negative", name))
+	}
+
+	return errs
+}
+
+// problems lists every invalid transport setting.
+func (t *TransportConfig) problems() []string {
+	var errs []string
+
+	if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 {
+		errs = append(errs, "transport connection limits cannot be negative")
+	}
+
+	if t.IdleConnTimeout < 0 || t.KeepAlive < 0 || t.DialTimeout < 0 ||
+		t.TLSHandshakeTimeout < 0 || t.ResponseHeaderTimeout < 0 {
+		errs = append(errs, "transport timeouts cannot be negative")
+	}
+
+	return errs
+}
+
+// problems lists every invalid proxy setting.
+func (p *ProxyConfig) problems() []string {
+	var errs []string
+
+	for _, proxy := range p.URLs {
+		if !validProxyURL(proxy) {
+			errs = append(errs, fmt.Sprintf("proxy %q must be an http, https, socks5 or socks5h URL", proxy))
+		}
+	}
+
+	if p.Rotation != "" && p.Rotation != "round-robin" && p.Rotation != "random" {
+		errs = append(errs, "proxy rotation must be either 'round-robin' or 'random'")
+	}
+
+	if p.MaxFailures < 0 {
+		errs = append(errs, "proxy max_failures cannot be negative")
+	}
+
+	if p.EjectFor < 0 {
+		errs = append(errs, "proxy eject_for cannot be negative")
+	}
+
+	for pattern, proxy := range p.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("proxy host pattern %q is invalid", pattern))
+		}
+		if proxy != ProxyDirect && !validProxyURL(proxy) {
+			errs = append(errs, fmt.Sprintf("proxy for %q must be 'direct' or an http, https, socks5 or socks5h URL", pattern))
+		}
+	}
+
+	return errs
+}
+
+// validProxyURL reports whether proxy is a URL the HTTP transport can dial through.
+func validProxyURL(proxy string) bool {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return true
+	}
+	return false
+}
+
+// problems lists every invalid session setting.
+func (s *SessionConfig) problems() []string {
+	var errs []string
+	login := s.Login
+
+	if login.URL == "" {
+		if len(login.Form) > 0 || len(login.FormEnv) > 0 {
+			errs = append(errs, "session login form needs a login url")
+		}
+		return errs
+	}
+
+	if u, err := url.Parse(login.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Sprintf("session login url %q must be an absolute http(s) URL", login.URL))
+	}
+
+	if login.Method != "" && !validHeaderName(login.Method) {
+		errs = append(errs, fmt.Sprintf("session login method %q is invalid", login.Method))
+	}
+
+	for field, env := range login.FormEnv {
+		if env == "" {
+			errs = append(errs, fmt.Sprintf("session login form_env for %q needs a variable name", field))
+		}
+	}
+
+	return errs
+}
+
+// validHeaderName reports whether name is a valid HTTP header field name (an RFC 9110 token).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validNamespace reports whether namespace is safe to use in bucket and directory names.
+func validNamespace(namespace string) bool {
+	for _, r := range namespace {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.' || r == '_' || r == '-':
+		default:
+			return false
+		}
+	}
+	return namespace != "." && namespace != ".."
+}
+
 // String returns a string representation of the config
 func (cfg *Config) String() string {
 	var buffer bytes.Buffer
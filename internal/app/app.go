@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/JesterSe7en/porygo/config"
 	"github.com/JesterSe7en/porygo/internal/logger"
@@ -22,6 +24,7 @@ type App struct {
 
 func New(log *logger.Logger, cfg *config.Config) (*App, error) {
 	manager := storage.GetCacheManager()
+	manager.Configure(cfg.Database)
 
 	cache, err := manager.GetCache()
 	if err != nil {
@@ -43,21 +46,52 @@ func New(log *logger.Logger, cfg *config.Config) (*App, error) {
 	}, nil
 }
 
-func (a *App) Run(ctx context.Context, urls []string) error {
+func (a *App) Run(ctx context.Context, targets []scraper.Target) error {
 	// create scraper client
-	scraperClient := scraper.New(a.cfg, a.log, a.cache)
+	scraperClient, err := scraper.New(a.cfg, a.log, a.cache)
+	if err != nil {
+		return err
+	}
+
+	// Only scrape runs prune: reextract needs expired responses, and expired
+	// entries are still useful offline, so they are kept.
+	if a.cfg.Database.PruneOnStartup && !a.cfg.Database.ReadOnly && !a.cfg.Offline {
+		pruned, err := scraperClient.PruneExpired(ctx)
+		if err != nil {
+			a.log.Warn("Failed to prune expired cache entries: %v", err)
+		} else {
+			a.log.Info("Pruned %d expired cache entries", pruned)
+		}
+	}
+
+	// log in before any URL is scraped; offline runs never touch the network
+	if !a.cfg.Offline {
+		if err := scraperClient.Login(ctx); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if err := scraperClient.SaveSession(); err != nil {
+			a.log.Error("Failed to save session: %v", err)
+		}
+	}()
 
 	// startup worker pool
 	pool := wp.New(a.cfg.Concurrency, a.cfg.Concurrency)
 	pool.Run(ctx, a.cfg.Concurrency)
 
+	// finished is written by one job per target and read once the pool is closed
+	targets = scraper.InterleaveByHost(targets)
+	finished := make([]bool, len(targets))
+
 	// create jobs for worker pool
 	go func() {
 		defer pool.Close()
-		for _, url := range urls {
-			scrapeURL := url
+		for i, target := range targets {
 			job := func() wp.Result {
-				return scraperClient.ScrapeWithRetry(scrapeURL)
+				res := scraperClient.ScrapeTarget(ctx, target)
+				finished[i] = !errors.Is(res.Err, context.Canceled)
+				return res
 			}
 
 			if err := pool.Submit(ctx, job); err != nil {
@@ -69,6 +103,10 @@ func (a *App) Run(ctx context.Context, urls []string) error {
 
 	// Process results as they come in
 	for res := range pool.Results() {
+		if errors.Is(res.Err, context.Canceled) {
+			continue // reported below
+		}
+
 		if res.Err != nil {
 			a.log.Error("Failed to get response: %s", res.Err.Error())
 			continue
@@ -79,5 +117,50 @@ func (a *App) Run(ctx context.Context, urls []string) error {
 		}
 	}
 
+	a.reportCanceled(targets, finished)
+	return nil
+}
+
+// reportCanceled lists the targets that were not scraped because the run was canceled.
+func (a *App) reportCanceled(targets []scraper.Target, finished []bool) {
+	var canceled []string
+	for i, target := range targets {
+		if !finished[i] {
+			canceled = append(canceled, target.URL)
+		}
+	}
+
+	if len(canceled) > 0 {
+		a.log.Warn("Canceled before %d of %d URLs were scraped: %s", len(canceled), len(targets), strings.Join(canceled, ", "))
+	}
+}
+
+// Reextract presents the results of re-running the configured selectors and
+// patterns against cached responses, optionally limited to urls.
+func (a *App) Reextract(ctx context.Context, urls []string) error {
+	scraperClient, err := scraper.New(a.cfg, a.log, a.cache)
+	if err != nil {
+		return err
+	}
+
+	count, err := scraperClient.Reextract(ctx, urls, func(res wp.Result) error {
+		if res.Err != nil {
+			a.log.Error("Failed to re-extract response: %s", res.Err.Error())
+			return nil
+		}
+
+		if err := a.presenter.Write(res.Value); err != nil {
+			a.log.Error("Failed to write output: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		a.log.Warn("No cached responses to re-extract")
+	}
+
 	return nil
 }
@@ -6,17 +6,24 @@
 package flags
 
 const (
-	FlagLog         = "log"          // path to log file
-	FlagDebug       = "debug"        // enable debug mode
-	FlagConfig      = "config"       // path to config file
-	FlagConcurrency = "concurrency"  // number of concurrent requests
-	FlagTimeout     = "timeout"      // timeout duration for requests
-	FlagVerbose     = "verbose"      // enable verbose mode
-	FlagRetry       = "retry"        // number of retries for failed requests
-	FlagRetryDelay  = "retry-delay"  // delay duration between retries
-	FlagRetryJitter = "retry-jitter" // enable jitter for retry delays
-	FlagBackoff     = "backoff"      // backoff duration between retries
-	FlagForce       = "force"        // ignore cache and scrape fresh data
+	FlagLog          = "log"            // path to log file
+	FlagDebug        = "debug"          // enable debug mode
+	FlagConfig       = "config"         // path to config file
+	FlagConcurrency  = "concurrency"    // number of concurrent requests
+	FlagTimeout      = "timeout"        // timeout duration for requests
+	FlagVerbose      = "verbose"        // enable verbose mode
+	FlagRetry        = "retry"          // number of retries for failed requests
+	FlagRetryDelay   = "retry-delay"    // delay duration between retries
+	FlagRetryJitter  = "retry-jitter"   // enable jitter for retry delays
+	FlagBackoff      = "backoff"        // backoff duration between retries
+	FlagForce        = "force"          // ignore cache and scrape fresh data
+	FlagOffline      = "offline"        // serve only from the cache
+	FlagStaleIfError = "stale-if-error" // serve expired cache entries when scraping fails
+
+	// Cache location flags, shared by every command
+	FlagCacheDir       = "cache-dir"       // directory holding the cache
+	FlagCacheNamespace = "cache-namespace" // isolate entries of one project
+	FlagCacheReadOnly  = "cache-read-only" // never write to the cache
 
 	// Scraper flags
 	FlagSelect  = "select"  // CSS selectors
@@ -24,4 +31,32 @@ const (
 	FlagFormat  = "format"  // output format json|csv|plain
 	FlagQuiet   = "quiet"   // only output extracted data
 	FlagHeaders = "headers" // include response headers
+
+	// Request flags
+	FlagHeader       = "header"        // extra request header as 'Name: value'
+	FlagCookie       = "cookie"        // cookie as name=value
+	FlagUserAgent    = "user-agent"    // User-Agent header
+	FlagMethod       = "method"        // HTTP method
+	FlagData         = "data"          // request body
+	FlagDataFile     = "data-file"     // file holding the request body
+	FlagJSONBody     = "json"          // JSON request body
+	FlagNetrc        = "netrc"         // look up credentials in the netrc file
+	FlagNetrcFile    = "netrc-file"    // netrc file to read credentials from
+	FlagCookieJar    = "cookie-jar"    // Netscape cookies.txt to load and save session cookies
+	FlagSession      = "session"       // keep cookies set by responses across requests
+	FlagProxy        = "proxy"         // proxy to route requests through
+	FlagInsecure     = "insecure"      // skip TLS certificate verification
+	FlagRateLimit    = "rate-limit"    // requests per second sent to each host
+	FlagDelay        = "delay"         // minimum delay between requests to the same host
+	FlagPerHost      = "per-host"      // requests in flight per host
+	FlagIgnoreRobots = "ignore-robots" // fetch URLs disallowed by robots.txt
+
+	// Cache flags
+	FlagJSON      = "json"       // print cache command output as JSON
+	FlagOlderThan = "older-than" // prune entries created longer ago than this
+	FlagHost      = "host"       // only act on entries whose host matches this glob
+	FlagNoCompact = "no-compact" // skip database compaction after pruning
+	FlagRepair    = "repair"     // delete corrupt entries found while verifying
+
+	FlagIncludeExpired = "include-expired" // also export or import expired entries
 )
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/JesterSe7en/porygo/internal/scraper"
 )
@@ -50,10 +52,31 @@ func (p *TextPresenter) Write(data any) error {
 	// --- Metadata ---
 	sb.WriteString("--- Metadata ---\n")
 	sb.WriteString(fmt.Sprintf("URL:          %s\n", scrapedData.URL))
+	if scrapedData.Skipped != "" {
+		sb.WriteString(fmt.Sprintf("Skipped:      %s\n", scrapedData.Skipped))
+		_, err := fmt.Fprintln(p.writer, sb.String())
+		return err
+	}
 	sb.WriteString(fmt.Sprintf("Status:       %d\n", scrapedData.Status))
 	sb.WriteString(fmt.Sprintf("Content-Type: %s\n", scrapedData.ContentType))
 	sb.WriteString(fmt.Sprintf("Size:         %d bytes\n", scrapedData.Size))
 	sb.WriteString(fmt.Sprintf("Response Time: %s\n", scrapedData.ResponseTime))
+	if scrapedData.Stale {
+		sb.WriteString(fmt.Sprintf("Stale:        fetched %s, cached copy has expired\n", scrapedData.Timestamp.Format(time.RFC3339)))
+	}
+
+	// --- Headers ---
+	if len(scrapedData.Headers) > 0 {
+		sb.WriteString("\n--- Headers ---\n")
+		names := make([]string, 0, len(scrapedData.Headers))
+		for name := range scrapedData.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("%s: %s\n", name, strings.Join(scrapedData.Headers[name], ", ")))
+		}
+	}
 
 	// --- Extracted Data ---
 	if len(scrapedData.Extracted) > 0 {
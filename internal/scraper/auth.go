@@ -0,0 +1,159 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+// Supported values of the auth type setting
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+)
+
+// credential is a resolved secret ready to be sent to a host.
+type credential struct {
+	username string
+	password string
+	token    string // bearer token, used instead of username and password when set
+}
+
+// identity hashes the credential so responses fetched with different
+// credentials are cached apart, without keeping the secret itself.
+func (c credential) identity() string {
+	h := sha256.New()
+	writeField(h, c.username)
+	writeField(h, c.password)
+	writeField(h, c.token)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// apply sets the Authorization header of req.
+func (c credential) apply(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		return
+	}
+	req.SetBasicAuth(c.username, c.password)
+}
+
+// credentials maps hosts to the credentials sent to them. Configured auth
+// globs take precedence over netrc machines, which only match exact hosts.
+type credentials struct {
+	byPattern map[string]credential
+	byMachine map[string]credential
+}
+
+// loadCredentials resolves the configured auth settings and netrc file. Hosts
+// whose secret cannot be read are logged and left without credentials.
+func loadCredentials(cfg config.RequestConfig, log *logger.Logger) *credentials {
+	creds := &credentials{
+		byPattern: make(map[string]credential),
+		byMachine: make(map[string]credential),
+	}
+
+	for pattern, auth := range cfg.Auth {
+		var cred credential
+		var env string
+		switch strings.ToLower(auth.Type) {
+		case AuthBasic:
+			env = auth.PasswordEnv
+			cred = credential{username: auth.Username, password: os.Getenv(env)}
+		case AuthBearer:
+			env = auth.TokenEnv
+			cred = credential{token: os.Getenv(env)}
+		default:
+			log.Warn("Ignoring auth for %s: unknown type %q", pattern, auth.Type)
+			continue
+		}
+
+		if os.Getenv(env) == "" {
+			log.Warn("Ignoring auth for %s: environment variable %s is not set", pattern, env)
+			continue
+		}
+		creds.byPattern[pattern] = cred
+	}
+
+	if cfg.Netrc {
+		path := cfg.NetrcFile
+		if path == "" {
+			path = defaultNetrcPath()
+		}
+
+		machines, err := readNetrc(path)
+		if err != nil {
+			log.Warn("Cannot read netrc file %s: %v", path, err)
+		}
+		for host, machine := range machines {
+			creds.byMachine[host] = credential{username: machine.login, password: machine.password}
+		}
+	}
+
+	return creds
+}
+
+// empty reports whether there are no credentials at all.
+func (c *credentials) empty() bool {
+	return len(c.byPattern) == 0 && len(c.byMachine) == 0
+}
+
+// lookup returns the credentials for the host of rawURL, preferring the most
+// specific matching auth glob.
+func (c *credentials) lookup(rawURL string) (credential, bool) {
+	if matches := matchingHostPatterns(slices.Collect(maps.Keys(c.byPattern)), rawURL); len(matches) > 0 {
+		return c.byPattern[matches[len(matches)-1]], true
+	}
+
+	host := hostOf(rawURL)
+	cred, ok := c.byMachine[host]
+	return cred, ok
+}
+
+// identity returns the identity of the credentials sent to the host of rawURL,
+// or an empty string when there are none.
+func (c *credentials) identity(rawURL string) string {
+	if c == nil {
+		return ""
+	}
+	cred, ok := c.lookup(rawURL)
+	if !ok {
+		return ""
+	}
+	return cred.identity()
+}
+
+// authTransport adds credentials for the destination of every request it sends.
+// It runs again for each redirect, so a redirect to another host only carries
+// that host's credentials, if any. Requests with an explicit Authorization
+// header are left alone.
+type authTransport struct {
+	base  http.RoundTripper
+	creds *credentials
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	cred, ok := t.creds.lookup(req.URL.String())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given.
+	authed := req.Clone(req.Context())
+	cred.apply(authed)
+	return t.base.RoundTrip(authed)
+}
@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestParseNetrc(t *testing.T) {
+	machines, err := parseNetrc(`
+# comment
+machine API.example.com login alice password s3cret
+machine other.example.com
+	login bob
+	password hunter2
+
+macdef init
+machine evil.example.com login mallory password x
+
+default login anyone password anything
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing netrc: %v", err)
+	}
+
+	expected := map[string]netrcMachine{
+		"api.example.com":   {login: "alice", password: "s3cret"},
+		"other.example.com": {login: "bob", password: "hunter2"},
+	}
+	if len(machines) != len(expected) {
+		t.Fatalf("Expected %d machines, but got %v", len(expected), machines)
+	}
+	for host, machine := range expected {
+		if machines[host] != machine {
+			t.Errorf("Expected %s to be %v, but got %v", host, machine, machines[host])
+		}
+	}
+}
+
+func TestAuthTransport(t *testing.T) {
+	var redirected string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "ok")
+	}))
+	defer other.Close()
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path == "/redirect" {
+			// Same server under another host name
+			http.Redirect(w, r, strings.Replace(other.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	t.Setenv("PORYGO_TEST_TOKEN", "t0ken")
+	cfg := config.Defaults()
+	cfg.Request.Auth = map[string]config.AuthConfig{
+		"127.0.0.1": {Type: AuthBearer, TokenEnv: "PORYGO_TEST_TOKEN"},
+	}
+	s := newTestScraper(t, cfg, newMapCache())
+
+	if res := s.ScrapeWithRetry(context.Background(), server.URL+"/page"); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	if authorization != "Bearer t0ken" {
+		t.Errorf("Expected the bearer token, but got %q", authorization)
+	}
+
+	if res := s.ScrapeWithRetry(context.Background(), server.URL+"/redirect"); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	if redirected != "" {
+		t.Errorf("Expected no credentials after a cross-host redirect, but got %q", redirected)
+	}
+
+	t.Run("Test missing secret", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Request.Auth = map[string]config.AuthConfig{
+			"127.0.0.1": {Type: AuthBasic, Username: "alice", PasswordEnv: "PORYGO_TEST_UNSET"},
+		}
+		s := newTestScraper(t, cfg, newMapCache())
+		if _, ok := s.client.Transport.(*authTransport); ok {
+			t.Errorf("Expected no auth transport without a readable secret")
+		}
+	})
+}
+
+func TestAuthCacheIdentity(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.Header.Get("Authorization") == "" {
+			fmt.Fprint(w, "<html><body><h1>Log in</h1></body></html>")
+			return
+		}
+		fmt.Fprint(w, "<html><body><h1>Welcome</h1></body></html>")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	anonymous := config.Defaults()
+	anonymous.SelectorsConfig.Select = []string{"h1"}
+	authed := anonymous
+	authed.Request.Auth = map[string]config.AuthConfig{
+		"127.0.0.1": {Type: AuthBearer, TokenEnv: "PORYGO_TEST_TOKEN"},
+	}
+	t.Setenv("PORYGO_TEST_TOKEN", "t0ken")
+
+	scrape := func(cfg config.Config) string {
+		t.Helper()
+		res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+		if res.Err != nil {
+			t.Fatalf("Unexpected error scraping: %v", res.Err)
+		}
+		return res.Value.(ScrapedData).Extracted["h1"][0]
+	}
+
+	if got := scrape(anonymous); got != "Log in" {
+		t.Fatalf("Expected the login page, but got %q", got)
+	}
+	if got := scrape(authed); got != "Welcome" {
+		t.Errorf("Expected the anonymous result not to be served with credentials, but got %q", got)
+	}
+	if got := scrape(anonymous); got != "Log in" {
+		t.Errorf("Expected the authenticated result not to be served without credentials, but got %q", got)
+	}
+	if got := scrape(authed); got != "Welcome" {
+		t.Errorf("Expected the authenticated result from the cache, but got %q", got)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("Expected one request per identity, but got %d", hits.Load())
+	}
+}
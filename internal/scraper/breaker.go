@@ -0,0 +1,146 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+// ErrCircuitOpen is wrapped by the ScrapeError returned while a host's circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// outcome is how a request reflects on the health of its host.
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota // the host answered
+	outcomeFailure                // the host could not be reached, timed out or failed with a 5xx
+	outcomeNeutral                // the request never reached the host, e.g. without a healthy proxy, or was canceled
+)
+
+// outcomeOf classifies the error of a request for the circuit breaker.
+func outcomeOf(err error) outcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+
+	var scrapeErr *ScrapeError
+	if !errors.As(err, &scrapeErr) {
+		return outcomeNeutral
+	}
+
+	switch scrapeErr.Kind {
+	case ErrorNetwork, ErrorTimeout, ErrorServer:
+		return outcomeFailure
+	case ErrorClient:
+		return outcomeSuccess
+	default:
+		return outcomeNeutral
+	}
+}
+
+// circuitBreaker fails requests to a host fast once it has failed threshold
+// times in a row. After the cooldown a single probe is let through: success
+// closes the circuit, failure opens it for another cooldown.
+type circuitBreaker struct {
+	cfg config.CircuitBreakerConfig
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+// circuit is the state of one host. It is closed while openedAt is zero.
+type circuit struct {
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the circuit last opened
+	probing  bool      // a probe is in flight while half-open
+}
+
+func newCircuitBreaker(cfg config.CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg, hosts: make(map[string]*circuit)}
+}
+
+// allow reports whether a request to host may be sent now.
+func (b *circuitBreaker) allow(host string, now time.Time) bool {
+	if b.cfg.Threshold == 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok || c.openedAt.IsZero() {
+		return true
+	}
+
+	if c.probing || now.Before(c.openedAt.Add(b.cfg.Cooldown)) {
+		return false
+	}
+
+	c.probing = true
+	return true
+}
+
+// record updates the circuit of host with the outcome of a request and
+// reports whether the circuit just opened.
+func (b *circuitBreaker) record(host string, result outcome, now time.Time) bool {
+	if b.cfg.Threshold == 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+
+	// A neutral probe leaves the circuit open, ready for the next probe.
+	wasOpen := !c.openedAt.IsZero()
+	c.probing = false
+
+	switch result {
+	case outcomeSuccess:
+		*c = circuit{}
+	case outcomeFailure:
+		if wasOpen {
+			c.openedAt = now
+			return false
+		}
+		c.failures++
+		if c.failures >= b.cfg.Threshold {
+			c.failures = 0
+			c.openedAt = now
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryBudget caps the retries of a whole run, so a failing host cannot spend
+// every URL's full retry count.
+type retryBudget struct {
+	unlimited bool
+	remaining atomic.Int64
+}
+
+func newRetryBudget(retries int) *retryBudget {
+	b := &retryBudget{unlimited: retries == 0}
+	b.remaining.Store(int64(retries))
+	return b
+}
+
+// take spends one retry and reports whether there was one left.
+func (b *retryBudget) take() bool {
+	return b.unlimited || b.remaining.Add(-1) >= 0
+}
@@ -0,0 +1,123 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(config.CircuitBreakerConfig{Threshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	const host = "example.com"
+
+	b.record(host, outcomeFailure, now)
+	b.record(host, outcomeSuccess, now)
+	b.record(host, outcomeFailure, now)
+	if !b.allow(host, now) {
+		t.Fatalf("Expected a success to reset the failure count")
+	}
+
+	if opened := b.record(host, outcomeFailure, now); !opened {
+		t.Fatalf("Expected the circuit to open after 2 consecutive failures")
+	}
+	if b.allow(host, now.Add(30*time.Second)) {
+		t.Errorf("Expected requests to fail fast while the circuit is open")
+	}
+	if !b.allow("other.com", now) {
+		t.Errorf("Expected other hosts to be unaffected")
+	}
+
+	probeAt := now.Add(2 * time.Minute)
+	if !b.allow(host, probeAt) {
+		t.Fatalf("Expected a probe after the cooldown")
+	}
+	if b.allow(host, probeAt) {
+		t.Errorf("Expected a single probe while half-open")
+	}
+
+	b.record(host, outcomeFailure, probeAt)
+	if b.allow(host, probeAt.Add(30*time.Second)) {
+		t.Errorf("Expected a failed probe to reopen the circuit")
+	}
+
+	probeAt = probeAt.Add(2 * time.Minute)
+	b.allow(host, probeAt)
+	b.record(host, outcomeSuccess, probeAt)
+	if !b.allow(host, probeAt) || !b.allow(host, probeAt) {
+		t.Errorf("Expected a successful probe to close the circuit")
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := newRetryBudget(2)
+	if !budget.take() || !budget.take() || budget.take() {
+		t.Errorf("Expected exactly 2 retries")
+	}
+
+	unlimited := newRetryBudget(0)
+	for range 100 {
+		if !unlimited.take() {
+			t.Fatalf("Expected an unlimited budget")
+		}
+	}
+}
+
+func TestCircuitOpenFailsFast(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := config.Defaults()
+	cfg.Retry = 3
+	cfg.Backoff.BaseDelay = time.Millisecond
+	cfg.CircuitBreaker = config.CircuitBreakerConfig{Threshold: 2, Cooldown: time.Minute}
+	s := newTestScraper(t, cfg, newMapCache())
+
+	for _, path := range []string{"/first", "/second"} {
+		res := s.ScrapeWithRetry(context.Background(), server.URL+path)
+
+		var scrapeErr *ScrapeError
+		if !errors.As(res.Err, &scrapeErr) || scrapeErr.Kind != ErrorCircuitOpen || !errors.Is(res.Err, ErrCircuitOpen) {
+			t.Errorf("Expected a circuit open error for %s, but got %v", path, res.Err)
+		}
+	}
+
+	if hits != 2 {
+		t.Errorf("Expected the circuit to stop requests after 2 failures, but got %d requests", hits)
+	}
+
+	t.Run("Test retry budget", func(t *testing.T) {
+		hits = 0
+		cfg.CircuitBreaker.Threshold = 0
+		cfg.Backoff.RetryBudget = 1
+		s := newTestScraper(t, cfg, newMapCache())
+
+		s.ScrapeWithRetry(context.Background(), server.URL+"/first")
+		s.ScrapeWithRetry(context.Background(), server.URL+"/second")
+		if hits != 3 {
+			t.Errorf("Expected 2 first attempts and 1 retry, but got %d requests", hits)
+		}
+	})
+
+	t.Run("Test open circuit spends no retry budget", func(t *testing.T) {
+		cfg.CircuitBreaker.Threshold = 2
+		cfg.Backoff.RetryBudget = 5
+		s := newTestScraper(t, cfg, newMapCache())
+
+		// The retry opens the circuit, so the third attempt and the second URL are never sent.
+		s.ScrapeWithRetry(context.Background(), server.URL+"/first")
+		s.ScrapeWithRetry(context.Background(), server.URL+"/second")
+		if remaining := s.budget.remaining.Load(); remaining != 4 {
+			t.Errorf("Expected only the sent retry to spend the budget, but %d of 5 are left", remaining)
+		}
+	})
+}
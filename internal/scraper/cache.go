@@ -0,0 +1,387 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	wp "github.com/JesterSe7en/porygo/internal/workerpool"
+)
+
+// cacheRecordVersion identifies the layout of cacheRecord. Bump it whenever the
+// record changes shape so entries written by older builds are treated as misses.
+const cacheRecordVersion = 1
+
+// ErrRecordVersion is returned when a cached record was written with a different layout.
+var ErrRecordVersion = errors.New("unsupported cache record version")
+
+// ErrNotCached is returned in offline mode for URLs missing from the cache.
+var ErrNotCached = errors.New("not in cache")
+
+// cacheRecord is the typed payload stored in storage.CacheEntry.Value. Response
+// records keep the raw body and headers of a fetch so it can be re-extracted
+// offline; result records hold the complete ScrapedData so a cache hit renders
+// exactly like a fresh scrape.
+type cacheRecord struct {
+	Version int
+	Data    ScrapedData
+	Header  http.Header
+	Body    []byte // raw response body, only set for response records
+}
+
+// encodeRecord serializes a cacheRecord using gob encoding.
+func encodeRecord(record cacheRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	record.Version = cacheRecordVersion
+
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		return nil, fmt.Errorf("failed to encode cache record: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeRecord deserializes a cacheRecord and rejects records of another version.
+func decodeRecord(value []byte) (cacheRecord, error) {
+	var record cacheRecord
+
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&record); err != nil {
+		return cacheRecord{}, fmt.Errorf("failed to decode cache record: %w", err)
+	}
+
+	if record.Version != cacheRecordVersion {
+		return cacheRecord{}, fmt.Errorf("%w: %d", ErrRecordVersion, record.Version)
+	}
+
+	return record, nil
+}
+
+// DecodeCacheValue decodes a cache value written by the scraper into the
+// ScrapedData it describes, including the stored response headers.
+func DecodeCacheValue(value []byte) (ScrapedData, error) {
+	record, err := decodeRecord(value)
+	if err != nil {
+		return ScrapedData{}, err
+	}
+
+	data := record.Data
+	data.Headers = record.Header
+	return data, nil
+}
+
+// ArchiveValue splits a cache value written by the scraper into the JSON
+// metadata and the raw response body stored in cache archives.
+func ArchiveValue(value []byte) (json.RawMessage, []byte, error) {
+	record, err := decodeRecord(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := record.Data
+	data.Headers = record.Header
+
+	var metadata bytes.Buffer
+	enc := json.NewEncoder(&metadata)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode cache metadata: %w", err)
+	}
+
+	return bytes.TrimSpace(metadata.Bytes()), record.Body, nil
+}
+
+// CacheValue encodes the metadata and body of an archived entry as a cache value.
+func CacheValue(metadata json.RawMessage, body []byte) ([]byte, error) {
+	var data ScrapedData
+	if err := json.Unmarshal(metadata, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode cache metadata: %w", err)
+	}
+
+	record := cacheRecord{Data: data, Header: data.Headers}
+	record.Data.Headers = nil
+	if len(body) > 0 {
+		record.Body = body
+	}
+
+	return encodeRecord(record)
+}
+
+// checkCache retrieves and validates cached data for the given key
+func (s *Scraper) checkCache(key string) *wp.Result {
+	record, ok := s.loadCacheRecord(key)
+	if !ok {
+		return nil
+	}
+
+	// Return valid cached result
+	s.log.Debug("Using cached data for %s, not expired yet", record.Data.URL)
+	return &wp.Result{
+		Value: s.present(record),
+		Err:   nil,
+	}
+}
+
+// checkResponseCache re-extracts a cached raw response when the request was
+// fetched before but not with the current selectors and patterns.
+func (s *Scraper) checkResponseCache(fingerprint Fingerprint) *wp.Result {
+	resp, ok := s.loadCacheRecord(fingerprint.ResponseKey())
+	if !ok {
+		return nil
+	}
+
+	s.log.Debug("Re-extracting cached response for %s", resp.Data.URL)
+	result := s.extractAndStore(fingerprint, resp, true)
+	return &result
+}
+
+// loadCacheRecord returns the unexpired record stored under key, discarding
+// entries that are expired or cannot be decoded. Expired responses carrying
+// validators are kept so they can be revalidated.
+func (s *Scraper) loadCacheRecord(key string) (cacheRecord, bool) {
+	record, expiration, ok := s.readCacheRecord(key)
+	if !ok {
+		return cacheRecord{}, false
+	}
+
+	if now := time.Now(); now.After(expiration) {
+		if !keepExpired(s.cfg, key, record, expiration, now) {
+			s.cleanupExpiredCache(key)
+		}
+		return cacheRecord{}, false
+	}
+
+	return record, true
+}
+
+// keepExpired reports whether a record that expired at expiration is still
+// useful at now: any record may be served offline or within the stale-if-error
+// window, and responses with validators can be revalidated within max_stale.
+func keepExpired(cfg *config.Config, key string, record cacheRecord, expiration, now time.Time) bool {
+	if cfg.Offline {
+		return true
+	}
+	if cfg.StaleIfError > 0 && now.Before(expiration.Add(cfg.StaleIfError)) {
+		return true
+	}
+	return cfg.Database.MaxStale > 0 && now.Before(expiration.Add(cfg.Database.MaxStale)) &&
+		strings.HasPrefix(key, responseKeyPrefix) && hasValidators(record)
+}
+
+// Prunable reports whether entry has expired at now and is of no further use
+// under cfg, so it can be removed. Scrapes, prune_on_startup and cache prune
+// all keep the same entries. Unreadable entries are always prunable.
+func Prunable(cfg *config.Config, key string, entry storage.CacheEntry, now time.Time) bool {
+	if !now.After(entry.ExpirationTime) {
+		return false
+	}
+
+	record, err := decodeRecord(entry.Value)
+	if err != nil {
+		return true
+	}
+
+	return !keepExpired(cfg, key, record, entry.ExpirationTime, now)
+}
+
+// staleResult serves a cached copy regardless of freshness, marking it stale
+// when it has expired. Copies expired longer than window ago are ignored unless
+// window is 0. Returns nil when there is no usable copy.
+func (s *Scraper) staleResult(fingerprint Fingerprint, window time.Duration) *wp.Result {
+	now := time.Now()
+	usable := func(expiration time.Time) bool {
+		return window <= 0 || now.Before(expiration.Add(window))
+	}
+
+	if record, expiration, ok := s.readCacheRecord(fingerprint.ResultKey()); ok && usable(expiration) {
+		data := s.present(record)
+		data.Stale = now.After(expiration)
+		return &wp.Result{Value: data, Err: nil}
+	}
+
+	if resp, expiration, ok := s.readCacheRecord(fingerprint.ResponseKey()); ok && usable(expiration) {
+		result, err := s.extract(resp)
+		if err != nil {
+			return &wp.Result{Value: nil, Err: err}
+		}
+
+		data := s.present(result)
+		data.Stale = now.After(expiration)
+		return &wp.Result{Value: data, Err: nil}
+	}
+
+	return nil
+}
+
+// scrapeOffline answers from the cache alone, serving expired copies as stale.
+func (s *Scraper) scrapeOffline(url string, fingerprint Fingerprint, cacheable bool) wp.Result {
+	if cacheable {
+		if cached := s.checkCache(fingerprint.ResultKey()); cached != nil {
+			return *cached
+		}
+		if cached := s.checkResponseCache(fingerprint); cached != nil {
+			return *cached
+		}
+		if cached := s.staleResult(fingerprint, 0); cached != nil {
+			return *cached
+		}
+	}
+
+	err := fmt.Errorf("cannot scrape %s offline: %w", url, ErrNotCached)
+	s.log.Error("%v", err)
+	return wp.Result{Value: nil, Err: err}
+}
+
+// loadRevalidatable returns the response stored under key, expired or not,
+// when it carries validators for a conditional request.
+func (s *Scraper) loadRevalidatable(key string) (cacheRecord, bool) {
+	record, _, ok := s.readCacheRecord(key)
+	if !ok || !hasValidators(record) {
+		return cacheRecord{}, false
+	}
+	return record, true
+}
+
+// readCacheRecord returns the record stored under key and its expiration time,
+// discarding entries that are corrupt or cannot be decoded.
+func (s *Scraper) readCacheRecord(key string) (cacheRecord, time.Time, bool) {
+	cached, err := s.cache.Get(context.Background(), key)
+
+	if errors.Is(err, storage.ErrCorrupt) {
+		s.log.Warn("Discarding corrupt cache entry %s: %v", key, err)
+		s.cleanupExpiredCache(key)
+		return cacheRecord{}, time.Time{}, false
+	}
+
+	if err != nil && err != storage.ErrNotFound {
+		s.log.Error("Failed to retrieve %s from cache: %v", key, err)
+		return cacheRecord{}, time.Time{}, false
+	}
+
+	if err == storage.ErrNotFound {
+		return cacheRecord{}, time.Time{}, false
+	}
+
+	record, err := decodeRecord(cached.Value)
+	if err != nil {
+		s.log.Warn("Discarding unreadable cache entry %s: %v", key, err)
+		s.cleanupExpiredCache(key)
+		return cacheRecord{}, time.Time{}, false
+	}
+
+	return record, cached.ExpirationTime, true
+}
+
+// storeCacheRecord stores a record in the cache under key
+func (s *Scraper) storeCacheRecord(key string, record cacheRecord) {
+	now := time.Now()
+	ttl, ok := s.entryTTL(record, now)
+	if !ok {
+		s.log.Debug("Response for %s must not be stored, skipping cache", record.Data.URL)
+		return
+	}
+
+	s.log.Debug("Adding %s to cache...", record.Data.URL)
+
+	value, err := encodeRecord(record)
+	if err != nil {
+		s.log.Error("Failed to encode %s for cache: %v", record.Data.URL, err)
+		return
+	}
+
+	entry := storage.CacheEntry{
+		ExpirationTime: now.Add(ttl),
+		CreatedAt:      now,
+		Value:          value,
+	}
+
+	err = s.cache.Set(context.Background(), key, entry)
+	if errors.Is(err, storage.ErrReadOnly) {
+		s.log.Debug("Cache is read-only, not storing %s", record.Data.URL)
+		return
+	}
+	if err != nil {
+		s.log.Error("Failed to store %s in cache: %v", record.Data.URL, err)
+		return
+	}
+
+	s.log.Debug("Cache put operation successful.")
+}
+
+// PruneExpired removes every entry that is Prunable under the scraper's config.
+func (s *Scraper) PruneExpired(ctx context.Context) (int, error) {
+	now := time.Now()
+	return s.cache.DeleteFunc(ctx, func(key string, entry storage.CacheEntry) bool {
+		return Prunable(s.cfg, key, entry, now)
+	})
+}
+
+// cleanupExpiredCache removes expired cache entries
+func (s *Scraper) cleanupExpiredCache(key string) {
+	s.log.Debug("Cached data for %s is old, discarding...", key)
+	err := s.cache.Delete(context.Background(), key)
+	if err != nil && !errors.Is(err, storage.ErrReadOnly) {
+		s.log.Error("Failed to delete %s from cache: %v", key, err)
+	}
+}
+
+// Reextract runs the configured selectors and patterns against every raw
+// response in the cache, including expired ones, without touching the network.
+// When urls is not empty only responses for those URLs are processed. Each
+// result is stored in the cache and passed to visit.
+func (s *Scraper) Reextract(ctx context.Context, urls []string, visit func(wp.Result) error) (int, error) {
+	wanted := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		wanted[url] = true
+	}
+
+	// Collect first so results can be written back once iteration is done.
+	var keys []string
+	var responses []cacheRecord
+	err := s.cache.ForEach(ctx, func(key string, entry storage.CacheEntry) error {
+		if !strings.HasPrefix(key, responseKeyPrefix) {
+			return nil
+		}
+
+		record, err := decodeRecord(entry.Value)
+		if err != nil {
+			s.log.Warn("Skipping unreadable cache entry %s: %v", key, err)
+			return nil
+		}
+
+		if len(wanted) > 0 && !wanted[record.Data.URL] {
+			return nil
+		}
+
+		keys = append(keys, key)
+		responses = append(responses, record)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cached responses: %w", err)
+	}
+
+	extraction := extractionHash(s.cfg.SelectorsConfig)
+	for i, resp := range responses {
+		fingerprint := Fingerprint{
+			Request:    strings.TrimPrefix(keys[i], responseKeyPrefix),
+			Extraction: extraction,
+		}
+
+		if err := visit(s.extractAndStore(fingerprint, resp, true)); err != nil {
+			return i, err
+		}
+	}
+
+	return len(responses), nil
+}
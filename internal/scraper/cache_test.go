@@ -0,0 +1,545 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	wp "github.com/JesterSe7en/porygo/internal/workerpool"
+)
+
+// mapCache is a minimal in-memory storage.CacheStorage used to observe what the scraper caches.
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]storage.CacheEntry
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{entries: make(map[string]storage.CacheEntry)}
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) (storage.CacheEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return storage.CacheEntry{}, storage.ErrNotFound
+	}
+	return entry, nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, entry storage.CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *mapCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *mapCache) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]storage.CacheEntry)
+	return nil
+}
+
+func (m *mapCache) ForEach(ctx context.Context, fn func(key string, entry storage.CacheEntry) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		if err := fn(key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mapCache) DeleteFunc(ctx context.Context, match func(key string, entry storage.CacheEntry) bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := 0
+	for key, entry := range m.entries {
+		if match(key, entry) {
+			delete(m.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *mapCache) Close() error { return nil }
+
+func newTestScraper(t *testing.T, cfg config.Config, cache storage.CacheStorage) *Scraper {
+	t.Helper()
+	log, err := logger.New("", false, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	// Tests only expect their own requests, not robots.txt fetches; see newRobotsTestScraper.
+	cfg.Robots.Enabled = false
+	s, err := New(&cfg, &log, cache)
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	return s
+}
+
+func TestCacheRecord(t *testing.T) {
+	t.Run("Test Encode and Decode", func(t *testing.T) {
+		data := ScrapedData{
+			URL:          "https://example.com",
+			Status:       200,
+			ContentType:  "text/html",
+			ResponseTime: 42 * time.Millisecond,
+			Timestamp:    time.Now(),
+			Extracted:    map[string][]string{"h1": {"Hello"}},
+			Matches:      map[string][]string{"l+": {"ll"}},
+		}
+
+		value, err := encodeRecord(cacheRecord{Data: data, Body: []byte("<h1>Hello</h1>")})
+		if err != nil {
+			t.Fatalf("unexpected error encoding record: %v", err)
+		}
+
+		record, err := decodeRecord(value)
+		if err != nil {
+			t.Fatalf("unexpected error decoding record: %v", err)
+		}
+
+		if record.Data.URL != data.URL || record.Data.Status != data.Status {
+			t.Errorf("Expected metadata %+v, but got %+v", data, record.Data)
+		}
+		if got := record.Data.Extracted["h1"]; len(got) != 1 || got[0] != "Hello" {
+			t.Errorf("Expected extracted [Hello], but got %v", got)
+		}
+		if got := record.Data.Matches["l+"]; len(got) != 1 || got[0] != "ll" {
+			t.Errorf("Expected matches [ll], but got %v", got)
+		}
+		if string(record.Body) != "<h1>Hello</h1>" {
+			t.Errorf("Expected body to round trip, but got %q", record.Body)
+		}
+	})
+
+	t.Run("Test Decode rejects garbage", func(t *testing.T) {
+		if _, err := decodeRecord([]byte("test-value")); err == nil {
+			t.Fatal("Expected error decoding a non-record value")
+		}
+	})
+}
+
+func TestArchiveValue(t *testing.T) {
+	record := cacheRecord{
+		Data:   ScrapedData{URL: "https://example.com", Status: 200, ContentType: "text/html", Extracted: map[string][]string{"h1": {"Hello"}}},
+		Header: http.Header{"Etag": {`"v1"`}},
+		Body:   []byte("<h1>Hello</h1>"),
+	}
+	value, err := encodeRecord(record)
+	if err != nil {
+		t.Fatalf("unexpected error encoding record: %v", err)
+	}
+
+	metadata, body, err := ArchiveValue(value)
+	if err != nil {
+		t.Fatalf("unexpected error archiving value: %v", err)
+	}
+	if string(body) != "<h1>Hello</h1>" {
+		t.Errorf("Expected the raw body, but got %q", body)
+	}
+	for _, want := range []string{`"url":"https://example.com"`, `"status":200`, `"Etag":["\"v1\""]`} {
+		if !strings.Contains(string(metadata), want) {
+			t.Errorf("Expected metadata to contain %s, but got %s", want, metadata)
+		}
+	}
+
+	value, err = CacheValue(metadata, body)
+	if err != nil {
+		t.Fatalf("unexpected error restoring value: %v", err)
+	}
+	got, err := decodeRecord(value)
+	if err != nil {
+		t.Fatalf("unexpected error decoding restored value: %v", err)
+	}
+	if got.Data.URL != record.Data.URL || got.Data.Status != record.Data.Status || got.Data.Headers != nil {
+		t.Errorf("Expected data %+v, but got %+v", record.Data, got.Data)
+	}
+	if got.Header.Get("ETag") != `"v1"` || string(got.Body) != string(record.Body) {
+		t.Errorf("Expected headers and body to round trip, but got %v and %q", got.Header, got.Body)
+	}
+	if extracted := got.Data.Extracted["h1"]; len(extracted) != 1 || extracted[0] != "Hello" {
+		t.Errorf("Expected extracted [Hello], but got %v", extracted)
+	}
+
+	if _, _, err := ArchiveValue([]byte("test-value")); err == nil {
+		t.Error("Expected error archiving a non-record value")
+	}
+}
+
+func TestScrapeWithRetryCache(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Hello, World!</h1></body></html>")
+	}))
+	defer server.Close()
+
+	cfg := config.Defaults()
+	cfg.SelectorsConfig.Select = []string{"h1"}
+	cache := newMapCache()
+	s := newTestScraper(t, cfg, cache)
+
+	fresh := s.ScrapeWithRetry(context.Background(), server.URL)
+	if fresh.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", fresh.Err)
+	}
+
+	cached := s.ScrapeWithRetry(context.Background(), server.URL)
+	if cached.Err != nil {
+		t.Fatalf("Unexpected error reading from cache: %v", cached.Err)
+	}
+
+	if hits.Load() != 1 {
+		t.Errorf("Expected 1 request to the server, but got %d", hits.Load())
+	}
+
+	data, ok := cached.Value.(ScrapedData)
+	if !ok {
+		t.Fatalf("Expected cached value to be ScrapedData, but got %T", cached.Value)
+	}
+
+	if data.URL != server.URL || data.Status != http.StatusOK {
+		t.Errorf("Expected cached metadata to match the fresh scrape, but got %+v", data)
+	}
+	if got := data.Extracted["h1"]; len(got) != 1 || got[0] != "Hello, World!" {
+		t.Errorf("Expected cached extraction [Hello, World!], but got %v", got)
+	}
+}
+
+func TestCheckCacheDiscardsUnreadableEntries(t *testing.T) {
+	cache := newMapCache()
+	s := newTestScraper(t, config.Defaults(), cache)
+
+	key := "https://example.com"
+	_ = cache.Set(context.Background(), key, storage.CacheEntry{
+		Value:          []byte("test-value"),
+		ExpirationTime: time.Now().Add(time.Hour),
+	})
+
+	if result := s.checkCache(key); result != nil {
+		t.Fatalf("Expected a miss for an unreadable entry, but got %+v", result)
+	}
+
+	if _, err := cache.Get(context.Background(), key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected unreadable entry to be deleted, but got %v", err)
+	}
+}
+
+func TestResponseCacheReuse(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Hello</h1><h2>World</h2></body></html>")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	cfg.SelectorsConfig.Select = []string{"h1"}
+	if res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+
+	t.Run("Test new selectors reuse the cached response", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.SelectorsConfig.Select = []string{"h2"}
+		res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+		if res.Err != nil {
+			t.Fatalf("Unexpected error scraping: %v", res.Err)
+		}
+
+		if hits.Load() != 1 {
+			t.Errorf("Expected 1 request to the server, but got %d", hits.Load())
+		}
+		if got := res.Value.(ScrapedData).Extracted["h2"]; len(got) != 1 || got[0] != "World" {
+			t.Errorf("Expected extraction [World], but got %v", got)
+		}
+	})
+
+	t.Run("Test Reextract", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.SelectorsConfig.Pattern = []string{"W.rld"}
+		s := newTestScraper(t, cfg, cache)
+
+		var results []ScrapedData
+		count, err := s.Reextract(context.Background(), nil, func(res wp.Result) error {
+			if res.Err != nil {
+				return res.Err
+			}
+			results = append(results, res.Value.(ScrapedData))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error re-extracting: %v", err)
+		}
+
+		if count != 1 || len(results) != 1 {
+			t.Fatalf("Expected 1 re-extracted response, but got %d", count)
+		}
+		if got := results[0].Matches["W.rld"]; len(got) != 1 || got[0] != "World" {
+			t.Errorf("Expected matches [World], but got %v", got)
+		}
+		if hits.Load() != 1 {
+			t.Errorf("Expected no further requests to the server, but got %d", hits.Load())
+		}
+	})
+}
+
+func TestRevalidation(t *testing.T) {
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Hello</h1></body></html>")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	cfg.SelectorsConfig.Select = []string{"h1"}
+	cfg.Database.Expiration = -time.Second
+	if res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+
+	cfg.Database.Expiration = time.Hour
+	res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+	if res.Err != nil {
+		t.Fatalf("Unexpected error revalidating: %v", res.Err)
+	}
+
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Fatalf("Expected 1 full and 1 conditional request, but got %d and %d", full.Load(), notModified.Load())
+	}
+
+	data := res.Value.(ScrapedData)
+	if data.Status != http.StatusOK {
+		t.Errorf("Expected the cached status %d, but got %d", http.StatusOK, data.Status)
+	}
+	if got := data.Extracted["h1"]; len(got) != 1 || got[0] != "Hello" {
+		t.Errorf("Expected extraction [Hello] from the cached body, but got %v", got)
+	}
+
+	fingerprint, _ := NewFingerprint(newTestRequest(server.URL), cfg.SelectorsConfig)
+	entry, err := cache.Get(context.Background(), fingerprint.ResponseKey())
+	if err != nil {
+		t.Fatalf("Expected the response to stay cached, but got %v", err)
+	}
+	if !entry.ExpirationTime.After(time.Now()) {
+		t.Errorf("Expected the 304 to refresh the expiration, but it is %v", entry.ExpirationTime)
+	}
+	record, _ := decodeRecord(entry.Value)
+	if record.Header.Get("Cache-Control") != "max-age=60" {
+		t.Errorf("Expected headers of the 304 to be merged, but got %v", record.Header)
+	}
+}
+
+func TestUnsolicitedNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	cfg.Retry = 1
+	cfg.Request.Headers = map[string]string{"If-None-Match": `"v1"`}
+	res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+
+	var scrapeErr *ScrapeError
+	if !errors.As(res.Err, &scrapeErr) || scrapeErr.StatusCode != http.StatusNotModified {
+		t.Fatalf("Expected a 304 status error, but got %v", res.Err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected nothing to be cached, but got %d entries", len(cache.entries))
+	}
+}
+
+func TestStaleCache(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Hello</h1></body></html>")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	cfg.Retry = 1
+	cfg.SelectorsConfig.Select = []string{"h1"}
+	cfg.Database.Expiration = -time.Minute
+	cfg.StaleIfError = time.Hour
+	if res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	failing.Store(true)
+
+	t.Run("Test stale-if-error", func(t *testing.T) {
+		res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+		if res.Err != nil {
+			t.Fatalf("Expected the expired copy to be served, but got %v", res.Err)
+		}
+		data := res.Value.(ScrapedData)
+		if !data.Stale {
+			t.Error("Expected the served copy to be marked stale")
+		}
+		if got := data.Extracted["h1"]; len(got) != 1 || got[0] != "Hello" {
+			t.Errorf("Expected extraction [Hello], but got %v", got)
+		}
+	})
+
+	t.Run("Test offline", func(t *testing.T) {
+		cfg := cfg
+		cfg.Offline = true
+		cfg.StaleIfError = 0
+		cfg.SelectorsConfig.Select = []string{"body"}
+		s := newTestScraper(t, cfg, cache)
+
+		res := s.ScrapeWithRetry(context.Background(), server.URL)
+		if res.Err != nil {
+			t.Fatalf("Expected the cached response to be re-extracted offline, but got %v", res.Err)
+		}
+		if data := res.Value.(ScrapedData); !data.Stale || len(data.Extracted["body"]) != 1 {
+			t.Errorf("Expected a stale extraction of body, but got %+v", data)
+		}
+
+		if res := s.ScrapeWithRetry(context.Background(), server.URL+"/missing"); !errors.Is(res.Err, ErrNotCached) {
+			t.Errorf("Expected error %v, but got %v", ErrNotCached, res.Err)
+		}
+	})
+
+	t.Run("Test stale-if-error window", func(t *testing.T) {
+		cfg := cfg
+		cfg.StaleIfError = time.Second
+		// Runs last: copies outside the window are discarded.
+		if res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL); res.Err == nil {
+			t.Fatal("Expected an error for a copy expired longer than the window")
+		}
+	})
+}
+
+func TestPruneExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	set := func(cache *mapCache, key string, record cacheRecord, expiration time.Time) {
+		t.Helper()
+		value, err := encodeRecord(record)
+		if err != nil {
+			t.Fatalf("Failed to encode record: %v", err)
+		}
+		cache.Set(ctx, key, storage.CacheEntry{Value: value, ExpirationTime: expiration, CreatedAt: now})
+	}
+
+	fill := func(cache *mapCache) {
+		validated := cacheRecord{Header: http.Header{"Etag": {`"v1"`}}}
+		set(cache, responseKeyPrefix+"fresh", cacheRecord{}, now.Add(time.Hour))
+		set(cache, responseKeyPrefix+"validators", validated, now.Add(-2*time.Hour))
+		set(cache, responseKeyPrefix+"validators-too-old", validated, now.Add(-8*24*time.Hour))
+		set(cache, responseKeyPrefix+"plain", cacheRecord{}, now.Add(-2*time.Hour))
+		set(cache, resultKeyPrefix+"validators", validated, now.Add(-2*time.Hour))
+		set(cache, resultKeyPrefix+"in-window", cacheRecord{}, now.Add(-time.Minute))
+		cache.Set(ctx, resultKeyPrefix+"garbage", storage.CacheEntry{Value: []byte("test-value"), ExpirationTime: now.Add(-time.Minute)})
+	}
+
+	cfg := config.Defaults()
+	cfg.StaleIfError = time.Hour
+	kept := []string{responseKeyPrefix + "fresh", responseKeyPrefix + "validators", resultKeyPrefix + "in-window"}
+
+	cache := newMapCache()
+	fill(cache)
+	pruned, err := newTestScraper(t, cfg, cache).PruneExpired(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error pruning: %v", err)
+	}
+	if pruned != 4 {
+		t.Errorf("Expected 4 pruned entries, but got %d", pruned)
+	}
+
+	for _, key := range kept {
+		if _, err := cache.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to survive pruning, but got %v", key, err)
+		}
+	}
+
+	t.Run("Test cache prune and lookups keep the same entries", func(t *testing.T) {
+		// cache prune deletes with Prunable directly.
+		prunedByCommand := newMapCache()
+		fill(prunedByCommand)
+		_, _ = prunedByCommand.DeleteFunc(ctx, func(key string, entry storage.CacheEntry) bool {
+			return Prunable(&cfg, key, entry, time.Now())
+		})
+
+		looked := newMapCache()
+		fill(looked)
+		s := newTestScraper(t, cfg, looked)
+		var keys []string
+		for key := range looked.entries {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			s.loadCacheRecord(key)
+		}
+
+		for name, other := range map[string]*mapCache{"cache prune": prunedByCommand, "lookups": looked} {
+			if len(other.entries) != len(kept) {
+				t.Errorf("Expected %d entries left after %s, but got %d", len(kept), name, len(other.entries))
+			}
+			for _, key := range kept {
+				if _, err := other.Get(ctx, key); err != nil {
+					t.Errorf("Expected %s to survive %s, but got %v", key, name, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Test offline keeps everything", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Offline = true
+		set(cache, responseKeyPrefix+"plain", cacheRecord{}, now.Add(-2*time.Hour))
+		pruned, err := newTestScraper(t, cfg, cache).PruneExpired(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error pruning: %v", err)
+		}
+		if pruned != 0 {
+			t.Errorf("Expected nothing pruned offline, but got %d", pruned)
+		}
+	})
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in the domain column of a cookies.txt file.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// cookieID identifies a stored cookie the way browsers do.
+type cookieID struct {
+	domain string
+	path   string
+	name   string
+}
+
+// storedCookie is a cookie with the attributes needed to write it back out.
+type storedCookie struct {
+	http.Cookie
+	hostOnly bool // sent to the exact domain only, not its subdomains
+}
+
+// cookieJar is a cookiejar.Jar that also remembers every cookie it was given,
+// since the standard jar cannot list its contents for saving.
+type cookieJar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	cookies map[cookieID]storedCookie
+}
+
+func newCookieJar() *cookieJar {
+	// Without a public suffix list the jar accepts cookies for the host and its parent domains.
+	jar, _ := cookiejar.New(nil)
+	return &cookieJar{jar: jar, cookies: make(map[cookieID]storedCookie)}
+}
+
+// SetCookies implements http.CookieJar. Only cookies the standard jar accepts
+// are remembered, so a site cannot plant cookies for another domain through
+// the saved cookie file.
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, cookie := range cookies {
+		domain, hostOnly, ok := cookieDomain(host, cookie.Domain)
+		if !ok {
+			continue
+		}
+
+		stored := storedCookie{Cookie: *cookie, hostOnly: hostOnly}
+		stored.Domain = domain
+		if stored.Path == "" || !strings.HasPrefix(stored.Path, "/") {
+			stored.Path = defaultCookiePath(u.Path)
+		}
+		if stored.MaxAge > 0 {
+			stored.Expires = time.Now().Add(time.Duration(stored.MaxAge) * time.Second)
+		}
+
+		id := cookieID{domain: stored.Domain, path: stored.Path, name: stored.Name}
+		if stored.MaxAge < 0 || (!stored.Expires.IsZero() && stored.Expires.Before(time.Now())) {
+			delete(j.cookies, id)
+			continue
+		}
+		j.cookies[id] = stored
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// identity hashes the cookies sent to rawURL, or returns an empty string when
+// there are none, so responses fetched in different sessions are cached apart.
+func (j *cookieJar) identity(rawURL string) string {
+	if j == nil {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	cookies := j.jar.Cookies(u)
+	if len(cookies) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+	sort.Strings(pairs)
+
+	h := sha256.New()
+	for _, pair := range pairs {
+		writeField(h, pair)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cookieDomain returns the domain a cookie set by host applies to and whether
+// it is sent to that exact host only. It follows cookiejar.Jar without a public
+// suffix list: ok is false for a domain that is neither host nor one of its
+// parents, and for a malformed one.
+func cookieDomain(host, domain string) (string, bool, bool) {
+	if domain == "" {
+		return host, true, true
+	}
+	if net.ParseIP(host) != nil {
+		return host, true, host == domain
+	}
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", false, false
+	}
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return "", false, false
+	}
+
+	return domain, false, true
+}
+
+// defaultCookiePath returns the path a cookie without Path applies to (RFC 6265 5.1.4).
+func defaultCookiePath(urlPath string) string {
+	if urlPath == "" || urlPath[0] != '/' {
+		return "/"
+	}
+	if dir := path.Dir(urlPath); dir != "." {
+		return dir
+	}
+	return "/"
+}
+
+// load adds the cookies of a Netscape cookies.txt file. Expired cookies are skipped.
+func (j *cookieJar) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		httpOnly := strings.HasPrefix(text, httpOnlyPrefix)
+		text = strings.TrimPrefix(text, httpOnlyPrefix)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", line, len(fields))
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid expiry %q", line, fields[4])
+		}
+
+		domain := strings.TrimPrefix(strings.ToLower(fields[0]), ".")
+		secure := strings.EqualFold(fields[3], "TRUE")
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = domain
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		j.SetCookies(&url.URL{Scheme: scheme, Host: domain, Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+
+	return scanner.Err()
+}
+
+// save writes every unexpired cookie in Netscape cookies.txt format.
+func (j *cookieJar) save(w io.Writer) error {
+	j.mu.Lock()
+	cookies := make([]storedCookie, 0, len(j.cookies))
+	now := time.Now()
+	for _, cookie := range j.cookies {
+		if cookie.Expires.IsZero() || cookie.Expires.After(now) {
+			cookies = append(cookies, cookie)
+		}
+	}
+	j.mu.Unlock()
+
+	sort.Slice(cookies, func(a, b int) bool {
+		x, y := cookies[a], cookies[b]
+		if x.Domain != y.Domain {
+			return x.Domain < y.Domain
+		}
+		if x.Path != y.Path {
+			return x.Path < y.Path
+		}
+		return x.Name < y.Name
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+	for _, cookie := range cookies {
+		domain := cookie.Domain
+		if !cookie.hostOnly {
+			domain = "." + domain
+		}
+		if cookie.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(!cookie.hostOnly), cookie.Path, netscapeBool(cookie.Secure),
+			expires, cookie.Name, cookie.Value)
+	}
+
+	return bw.Flush()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
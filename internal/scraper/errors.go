@@ -0,0 +1,125 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ErrorKind classifies why a scrape failed.
+type ErrorKind string
+
+// Kinds of ScrapeError
+const (
+	ErrorNetwork     ErrorKind = "network"      // the connection failed or broke off
+	ErrorTimeout     ErrorKind = "timeout"      // the request took longer than the timeout
+	ErrorClient      ErrorKind = "client"       // the server answered with a 4xx status
+	ErrorServer      ErrorKind = "server"       // the server answered with a 5xx or other unexpected status
+	ErrorParse       ErrorKind = "parse"        // the URL, request or response could not be understood
+	ErrorCanceled    ErrorKind = "canceled"     // the run was canceled before the scrape finished
+	ErrorCircuitOpen ErrorKind = "circuit_open" // the host failed too often recently, so no request was sent
+	ErrorProxy       ErrorKind = "proxy"        // every proxy was ejected, so no request was sent
+)
+
+// ScrapeError describes a failed scrape.
+type ScrapeError struct {
+	Kind       ErrorKind
+	URL        string
+	StatusCode int           // set for client and server errors
+	RetryAfter time.Duration // wait asked for by a Retry-After header, 0 if none
+	Err        error         // underlying error, nil for status errors
+}
+
+func (e *ScrapeError) Error() string {
+	switch e.Kind {
+	case ErrorClient, ErrorServer:
+		return fmt.Sprintf("request failed with status code: %d", e.StatusCode)
+	case ErrorTimeout:
+		return fmt.Sprintf("request timed out: %v", e.Err)
+	case ErrorCanceled:
+		return fmt.Sprintf("scrape of %s canceled", e.URL)
+	case ErrorCircuitOpen:
+		return fmt.Sprintf("circuit open for %s after repeated failures, not sending request", hostOf(e.URL))
+	default:
+		return fmt.Sprintf("%s error: %v", e.Kind, e.Err)
+	}
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether retrying may succeed: network errors and timeouts
+// always, status errors only for the given statuses, parse errors, cancellation
+// and open circuits never.
+func (e *ScrapeError) retryable(statuses []int) bool {
+	switch e.Kind {
+	case ErrorNetwork, ErrorTimeout:
+		return true
+	case ErrorClient, ErrorServer:
+		return slices.Contains(statuses, e.StatusCode)
+	default:
+		return false
+	}
+}
+
+// requestError classifies an error returned while sending a request or reading its response.
+func requestError(url string, err error) *ScrapeError {
+	if errors.Is(err, context.Canceled) {
+		return canceledError(url, err)
+	}
+	if errors.Is(err, ErrNoProxy) {
+		return &ScrapeError{Kind: ErrorProxy, URL: url, Err: err}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &ScrapeError{Kind: ErrorTimeout, URL: url, Err: err}
+	}
+	return &ScrapeError{Kind: ErrorNetwork, URL: url, Err: err}
+}
+
+// canceledError describes a scrape stopped because the run was canceled.
+func canceledError(url string, err error) *ScrapeError {
+	return &ScrapeError{Kind: ErrorCanceled, URL: url, Err: err}
+}
+
+// statusError describes an unexpected response status.
+func statusError(url string, res *http.Response, now time.Time) *ScrapeError {
+	kind := ErrorServer
+	if res.StatusCode >= 400 && res.StatusCode < 500 {
+		kind = ErrorClient
+	}
+
+	return &ScrapeError{
+		Kind:       kind,
+		URL:        url,
+		StatusCode: res.StatusCode,
+		RetryAfter: retryAfter(res.Header.Get("Retry-After"), now),
+	}
+}
+
+// retryAfter parses a Retry-After value, either delay seconds or an HTTP date.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,131 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Wed, 01 Jan 2025 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.value, now); got != tt.expected {
+			t.Errorf("Expected Retry-After %q to be %v, but got %v", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestRetryClassification(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/busy":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.Defaults()
+	cfg.Retry = 2
+	cfg.Backoff.BaseDelay = time.Millisecond
+	cfg.Backoff.Jitter = false
+	cfg.Backoff.MaxRetryAfter = 50 * time.Millisecond
+	s := newTestScraper(t, cfg, newMapCache())
+
+	tests := []struct {
+		name  string
+		url   string
+		kind  ErrorKind
+		hits  int
+		delay time.Duration
+	}{
+		{"Test permanent client error", server.URL + "/missing", ErrorClient, 1, 0},
+		{"Test Retry-After capped", server.URL + "/busy", ErrorServer, 2, 50 * time.Millisecond},
+		{"Test invalid URL", "ftp://example.com/file", ErrorParse, 0, 0},
+		{"Test network error", "http://127.0.0.1:1/", ErrorNetwork, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits = 0
+			start := time.Now()
+			res := s.ScrapeWithRetry(context.Background(), tt.url)
+
+			var scrapeErr *ScrapeError
+			if !errors.As(res.Err, &scrapeErr) {
+				t.Fatalf("Expected a ScrapeError, but got %v", res.Err)
+			}
+			if scrapeErr.Kind != tt.kind {
+				t.Errorf("Expected a %s error, but got %s: %v", tt.kind, scrapeErr.Kind, scrapeErr)
+			}
+			if hits != tt.hits {
+				t.Errorf("Expected %d requests, but got %d", tt.hits, hits)
+			}
+			if elapsed := time.Since(start); elapsed < tt.delay || elapsed > tt.delay+time.Second {
+				t.Errorf("Expected to wait about %v, but took %v", tt.delay, elapsed)
+			}
+		})
+	}
+}
+
+func TestScrapeCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := config.Defaults()
+	cfg.Retry = 5
+	cfg.Backoff.BaseDelay = 10 * time.Second
+	cfg.Backoff.Jitter = false
+	cfg.Timeout = 10 * time.Second
+	s := newTestScraper(t, cfg, newMapCache())
+
+	for _, path := range []string{"/unavailable", "/hang"} {
+		t.Run("Test cancel "+path, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				cancel()
+			}()
+
+			start := time.Now()
+			res := s.ScrapeWithRetry(ctx, server.URL+path)
+
+			var scrapeErr *ScrapeError
+			if !errors.As(res.Err, &scrapeErr) || scrapeErr.Kind != ErrorCanceled {
+				t.Errorf("Expected a canceled error, but got %v", res.Err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Expected the scrape to stop at once, but it took %v", elapsed)
+			}
+		})
+	}
+}
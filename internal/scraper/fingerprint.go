@@ -0,0 +1,140 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+const (
+	resultKeyPrefix   = "result/"
+	responseKeyPrefix = "response/"
+)
+
+// volatileHeaders do not change what a server sends back for a request and are
+// left out of the fingerprint so they cannot split otherwise identical entries.
+var volatileHeaders = map[string]bool{
+	"Cache-Control":     true,
+	"Connection":        true,
+	"If-Modified-Since": true,
+	"If-None-Match":     true,
+	"Pragma":            true,
+}
+
+// Fingerprint identifies a scrape in the cache. Request covers everything that
+// influences the raw response (URL, method, headers, body and the identity of
+// the credentials sent along) while Extraction
+// covers the selectors and patterns applied to it, so a response can be shared
+// between runs that only differ in what they extract.
+type Fingerprint struct {
+	Request    string
+	Extraction string
+}
+
+// NewFingerprint computes the fingerprint of req extracted with selectors.
+func NewFingerprint(req Request, selectors config.SelectorsConfig) (Fingerprint, error) {
+	normalized, err := normalizeURL(req.URL)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+
+	h := sha256.New()
+	writeField(h, strings.ToUpper(req.Method))
+	writeField(h, normalized)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		canonical := http.CanonicalHeaderKey(name)
+		if !volatileHeaders[canonical] {
+			names = append(names, canonical)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeField(h, name+": "+strings.Join(req.Header.Values(name), ", "))
+	}
+
+	bodySum := sha256.Sum256(req.Body)
+	writeField(h, hex.EncodeToString(bodySum[:]))
+
+	// Left out when empty so anonymous requests keep their existing keys.
+	if req.Identity != "" {
+		writeField(h, "identity:"+req.Identity)
+	}
+
+	return Fingerprint{
+		Request:    hex.EncodeToString(h.Sum(nil)),
+		Extraction: extractionHash(selectors),
+	}, nil
+}
+
+// ResultKey is the cache key of the extracted ScrapedData for this fingerprint.
+func (f Fingerprint) ResultKey() string {
+	return resultKeyPrefix + f.Request + "/" + f.Extraction
+}
+
+// ResponseKey is the cache key of the raw response for this fingerprint. It is
+// shared by every extraction spec applied to the same request.
+func (f Fingerprint) ResponseKey() string {
+	return responseKeyPrefix + f.Request
+}
+
+// extractionHash hashes the extraction spec. Order is preserved because the
+// regex pass runs over selector output in selector order.
+func extractionHash(selectors config.SelectorsConfig) string {
+	h := sha256.New()
+	for _, selector := range selectors.Select {
+		writeField(h, "select:"+selector)
+	}
+	for _, pattern := range selectors.Pattern {
+		writeField(h, "pattern:"+pattern)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeURL returns a canonical form of rawURL: lowercase scheme and host,
+// default ports and fragments removed, an explicit root path and sorted query parameters.
+func normalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.RawQuery = u.Query().Encode()
+
+	return u.String(), nil
+}
+
+// writeField writes a length-prefixed field so adjacent fields cannot run together.
+func writeField(h hash.Hash, field string) {
+	fmt.Fprintf(h, "%d:%s\n", len(field), field)
+}
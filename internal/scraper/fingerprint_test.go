@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func newTestRequest(url string) Request {
+	header := make(http.Header)
+	header.Set("User-Agent", defaultUserAgent)
+	return Request{URL: url, Method: http.MethodGet, Header: header}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://Example.COM", "https://example.com/"},
+		{"https://example.com:443/path#section", "https://example.com/path"},
+		{"http://example.com:80/", "http://example.com/"},
+		{"http://example.com:8080/", "http://example.com:8080/"},
+		{"https://example.com/?b=2&a=1", "https://example.com/?a=1&b=2"},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.input)
+		if err != nil {
+			t.Fatalf("unexpected error normalizing %s: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Expected %s to normalize to %s, but got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestNewFingerprint(t *testing.T) {
+	selectors := config.SelectorsConfig{Select: []string{"h1"}}
+
+	base, err := NewFingerprint(newTestRequest("https://example.com/?a=1&b=2"), selectors)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("Test equivalent URLs share a fingerprint", func(t *testing.T) {
+		other, _ := NewFingerprint(newTestRequest("https://EXAMPLE.com:443/?b=2&a=1#top"), selectors)
+		if other != base {
+			t.Errorf("Expected equivalent URLs to produce the same fingerprint")
+		}
+	})
+
+	t.Run("Test selectors only change the extraction hash", func(t *testing.T) {
+		other, _ := NewFingerprint(newTestRequest("https://example.com/?a=1&b=2"), config.SelectorsConfig{Select: []string{"h2"}})
+		if other.Request != base.Request {
+			t.Errorf("Expected request hash to be unaffected by selectors")
+		}
+		if other.Extraction == base.Extraction {
+			t.Errorf("Expected extraction hash to change with selectors")
+		}
+		if other.ResultKey() == base.ResultKey() {
+			t.Errorf("Expected result keys to differ")
+		}
+	})
+
+	t.Run("Test method, headers, body and identity change the request hash", func(t *testing.T) {
+		post := newTestRequest("https://example.com/?a=1&b=2")
+		post.Method = http.MethodPost
+		withHeader := newTestRequest("https://example.com/?a=1&b=2")
+		withHeader.Header.Set("Accept", "application/json")
+		withBody := newTestRequest("https://example.com/?a=1&b=2")
+		withBody.Body = []byte(`{"q":"porygo"}`)
+		withIdentity := newTestRequest("https://example.com/?a=1&b=2")
+		withIdentity.Identity = credential{token: "t0ken"}.identity()
+
+		for name, req := range map[string]Request{"method": post, "header": withHeader, "body": withBody, "identity": withIdentity} {
+			other, _ := NewFingerprint(req, selectors)
+			if other.Request == base.Request {
+				t.Errorf("Expected %s to change the request hash", name)
+			}
+		}
+	})
+
+	t.Run("Test volatile headers are ignored", func(t *testing.T) {
+		req := newTestRequest("https://example.com/?a=1&b=2")
+		req.Header.Set("If-None-Match", `"abc"`)
+		other, _ := NewFingerprint(req, selectors)
+		if other != base {
+			t.Errorf("Expected conditional headers not to affect the fingerprint")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// matchingHostPatterns returns the host globs from patterns that match the host
+// of rawURL, least specific first: globs ordered by length, then an exact match.
+func matchingHostPatterns(patterns []string, rawURL string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+
+	var matches []string
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), host); matched {
+			matches = append(matches, pattern)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if exactA, exactB := strings.EqualFold(a, host), strings.EqualFold(b, host); exactA != exactB {
+			return exactB
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
+	return matches
+}
+
+// hostOf returns the lower-cased host name of rawURL, without port.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcMachine holds the login of one machine entry of a netrc file.
+type netrcMachine struct {
+	login    string
+	password string
+}
+
+// defaultNetrcPath returns $NETRC, or ~/.netrc (~/_netrc on Windows).
+func defaultNetrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+	return filepath.Join(home, name)
+}
+
+// readNetrc reads the machine entries of the netrc file at path.
+func readNetrc(path string) (map[string]netrcMachine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseNetrc(string(data))
+}
+
+// parseNetrc parses the machine, login and password tokens of a netrc file,
+// keyed by lower-cased host. The default entry is ignored on purpose: it would
+// send the same credentials to every host. Macro definitions are skipped.
+func parseNetrc(data string) (map[string]netrcMachine, error) {
+	machines := make(map[string]netrcMachine)
+
+	var host string
+	var current netrcMachine
+	inMachine := false
+	flush := func() {
+		if inMachine && host != "" {
+			machines[host] = current
+		}
+		host, current, inMachine = "", netrcMachine{}, false
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// A macro definition ends at the first empty line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			token := fields[i]
+			if strings.HasPrefix(token, "#") {
+				break
+			}
+
+			switch token {
+			case "machine", "default":
+				flush()
+				if token == "default" {
+					continue
+				}
+				if i+1 >= len(fields) {
+					return nil, fmt.Errorf("netrc: machine without name")
+				}
+				i++
+				host, inMachine = strings.ToLower(fields[i]), true
+			case "login", "password", "account":
+				if i+1 >= len(fields) {
+					return nil, fmt.Errorf("netrc: %s without value", token)
+				}
+				i++
+				switch token {
+				case "login":
+					current.login = fields[i]
+				case "password":
+					current.password = fields[i]
+				}
+			case "macdef":
+				flush()
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("netrc: %w", err)
+	}
+	flush()
+
+	return machines, nil
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+// ErrNoProxy is returned when every proxy of the pool has been ejected. The
+// request is never sent, so it fails with ErrorProxy.
+var ErrNoProxy = errors.New("no healthy proxy available")
+
+// proxyKey is the context key under which proxyTransport passes its choice to
+// the Proxy function of the underlying http.Transport.
+type proxyKey struct{}
+
+// proxyChoice is the proxy picked for a request; a nil state means direct.
+type proxyChoice struct {
+	state *proxyState
+}
+
+// proxyFromContext routes requests through the proxy chosen by proxyTransport,
+// falling back to HTTP_PROXY, HTTPS_PROXY and NO_PROXY for other requests.
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	choice, ok := req.Context().Value(proxyKey{}).(proxyChoice)
+	if !ok {
+		return http.ProxyFromEnvironment(req)
+	}
+	if choice.state == nil {
+		return nil, nil
+	}
+	return choice.state.url, nil
+}
+
+// proxyState tracks the health of one proxy.
+type proxyState struct {
+	url          *url.URL
+	failures     int       // consecutive failures
+	ejectedUntil time.Time // zero while the proxy is in rotation
+}
+
+// proxyPool rotates requests between proxies, ejecting those that fail
+// max_failures times in a row for eject_for.
+type proxyPool struct {
+	log         *logger.Logger
+	random      bool
+	maxFailures int
+	ejectFor    time.Duration
+
+	mu      sync.Mutex
+	proxies []*proxyState
+	next    int
+}
+
+// proxyRouter picks the proxy for a request: the most specific host rule
+// first, then NO_PROXY, then the pool.
+type proxyRouter struct {
+	hosts   map[string]*proxyState // nil state routes directly
+	noProxy []string               // NO_PROXY entries sent around the pool
+	pool    *proxyPool             // nil without a pool
+}
+
+// newProxyRouter returns a router for the configured proxies, or nil when none
+// are configured and the environment should be used instead. The config has
+// been validated, so unparsable URLs are skipped.
+func newProxyRouter(cfg config.ProxyConfig, log *logger.Logger) *proxyRouter {
+	if len(cfg.URLs) == 0 && len(cfg.Hosts) == 0 {
+		return nil
+	}
+
+	router := &proxyRouter{
+		hosts:   make(map[string]*proxyState, len(cfg.Hosts)),
+		noProxy: noProxyFromEnvironment(),
+	}
+	for pattern, proxy := range cfg.Hosts {
+		if proxy == config.ProxyDirect {
+			router.hosts[pattern] = nil
+			continue
+		}
+		if u, err := url.Parse(proxy); err == nil {
+			router.hosts[pattern] = &proxyState{url: u}
+		}
+	}
+
+	if len(cfg.URLs) > 0 {
+		pool := &proxyPool{
+			log:         log,
+			random:      cfg.Rotation == "random",
+			maxFailures: cfg.MaxFailures,
+			ejectFor:    cfg.EjectFor,
+		}
+		for _, proxy := range cfg.URLs {
+			if u, err := url.Parse(proxy); err == nil {
+				pool.proxies = append(pool.proxies, &proxyState{url: u})
+			}
+		}
+		router.pool = pool
+	}
+
+	return router
+}
+
+// route returns the proxy for the request to rawURL. Hosts matching no rule
+// with no pool configured fall back to the environment.
+func (r *proxyRouter) route(rawURL string) (proxyChoice, bool, error) {
+	if matches := matchingHostPatterns(slices.Collect(maps.Keys(r.hosts)), rawURL); len(matches) > 0 {
+		return proxyChoice{state: r.hosts[matches[len(matches)-1]]}, true, nil
+	}
+
+	if r.pool == nil {
+		return proxyChoice{}, false, nil
+	}
+
+	if bypassesProxy(r.noProxy, hostOf(rawURL)) {
+		return proxyChoice{}, true, nil
+	}
+
+	state, err := r.pool.pick(time.Now())
+	if err != nil {
+		return proxyChoice{}, false, err
+	}
+	return proxyChoice{state: state}, true, nil
+}
+
+// noProxyFromEnvironment returns the entries of NO_PROXY, or no_proxy when it is unset.
+func noProxyFromEnvironment() []string {
+	value := os.Getenv("NO_PROXY")
+	if value == "" {
+		value = os.Getenv("no_proxy")
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// bypassesProxy reports whether a NO_PROXY entry exempts host: "*", an IP
+// address or CIDR range, or a domain matching itself and its subdomains, with
+// or without a leading "." or "*.". Ports in entries are ignored.
+func bypassesProxy(entries []string, host string) bool {
+	ip := net.ParseIP(host)
+
+	for _, entry := range entries {
+		if entry == "*" {
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.Trim(entry, "[]")
+
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pick returns the next proxy in rotation, readmitting proxies whose ejection has expired.
+func (p *proxyPool) pick(now time.Time) (*proxyState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var healthy []*proxyState
+	for _, proxy := range p.proxies {
+		if !proxy.ejectedUntil.IsZero() && now.After(proxy.ejectedUntil) {
+			proxy.ejectedUntil = time.Time{}
+			p.log.Info("Proxy %s is back in rotation", proxy.url.Redacted())
+		}
+		if proxy.ejectedUntil.IsZero() {
+			healthy = append(healthy, proxy)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil, ErrNoProxy
+	}
+
+	if p.random {
+		return healthy[rand.Intn(len(healthy))], nil
+	}
+
+	proxy := healthy[p.next%len(healthy)]
+	p.next++
+	return proxy, nil
+}
+
+// report records the outcome of a request sent through proxy.
+func (p *proxyPool) report(proxy *proxyState, failed bool, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !failed {
+		proxy.failures = 0
+		return
+	}
+
+	proxy.failures++
+	if p.maxFailures > 0 && proxy.failures >= p.maxFailures && proxy.ejectedUntil.IsZero() {
+		proxy.failures = 0
+		proxy.ejectedUntil = now.Add(p.ejectFor)
+		p.log.Warn("Ejecting proxy %s for %v after %d consecutive failures", proxy.url.Redacted(), p.ejectFor, p.maxFailures)
+	}
+}
+
+// proxyTransport picks a proxy for every request, including redirects, and
+// feeds the outcome back to the pool. Connection errors and 407 Proxy
+// Authentication Required count as failures of the proxy.
+type proxyTransport struct {
+	base   http.RoundTripper
+	router *proxyRouter
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	choice, ok, err := t.router.route(req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	routed := req.WithContext(context.WithValue(req.Context(), proxyKey{}, choice))
+	res, err := t.base.RoundTrip(routed)
+
+	// Only pooled proxies rotate; host rules always use their proxy.
+	if choice.state != nil && t.router.pool != nil && slices.Contains(t.router.pool.proxies, choice.state) {
+		failed := (err != nil && req.Context().Err() == nil) ||
+			(res != nil && res.StatusCode == http.StatusProxyAuthRequired)
+		t.router.pool.report(choice.state, failed, time.Now())
+	}
+
+	return res, err
+}
@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+func TestProxyPool(t *testing.T) {
+	log, err := logger.New("", false, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	cfg := config.Defaults().Proxy
+	cfg.URLs = []string{"http://a.proxy:8080", "socks5://b.proxy:1080"}
+	cfg.MaxFailures = 2
+	cfg.EjectFor = time.Minute
+	pool := newProxyRouter(cfg, &log).pool
+
+	now := time.Now()
+	var picked []string
+	for range 4 {
+		proxy, err := pool.pick(now)
+		if err != nil {
+			t.Fatalf("Unexpected error picking a proxy: %v", err)
+		}
+		picked = append(picked, proxy.url.Host)
+	}
+	if fmt.Sprint(picked) != "[a.proxy:8080 b.proxy:1080 a.proxy:8080 b.proxy:1080]" {
+		t.Errorf("Expected round-robin rotation, but got %v", picked)
+	}
+
+	a := pool.proxies[0]
+	pool.report(a, true, now)
+	pool.report(a, false, now)
+	pool.report(a, true, now)
+	if !a.ejectedUntil.IsZero() {
+		t.Fatalf("Expected a success to reset the failure count")
+	}
+	pool.report(a, true, now)
+	if a.ejectedUntil.IsZero() {
+		t.Fatalf("Expected the proxy to be ejected after 2 consecutive failures")
+	}
+
+	for range 3 {
+		if proxy, _ := pool.pick(now); proxy == a {
+			t.Errorf("Expected the ejected proxy to be left out of the rotation")
+		}
+	}
+
+	pool.report(pool.proxies[1], true, now)
+	pool.report(pool.proxies[1], true, now)
+	if _, err := pool.pick(now); !errors.Is(err, ErrNoProxy) {
+		t.Errorf("Expected ErrNoProxy with every proxy ejected, but got %v", err)
+	}
+
+	if proxy, err := pool.pick(now.Add(2 * time.Minute)); err != nil || proxy == nil {
+		t.Errorf("Expected ejected proxies to return after eject_for, but got %v", err)
+	}
+}
+
+func TestProxyTransport(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target.
+		requested = r.URL.String()
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "proxied")
+	}))
+	defer proxy.Close()
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	cfg := config.Defaults()
+	cfg.Backoff.BaseDelay = time.Millisecond
+	cfg.Proxy.URLs = []string{dead.URL, proxy.URL}
+	cfg.Proxy.MaxFailures = 1
+	s := newTestScraper(t, cfg, newMapCache())
+
+	if res := s.ScrapeWithRetry(context.Background(), "http://scrape.example/page"); res.Err != nil {
+		t.Fatalf("Expected the retry to use the healthy proxy, but got %v", res.Err)
+	}
+	if requested != "http://scrape.example/page" {
+		t.Errorf("Expected the proxy to receive the target URL, but got %q", requested)
+	}
+
+	router := s.client.Transport.(*proxyTransport).router
+	if router.pool.proxies[0].ejectedUntil.IsZero() {
+		t.Errorf("Expected the unreachable proxy to be ejected")
+	}
+
+	t.Run("Test host rule", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Proxy.Hosts = map[string]string{"*.example": proxy.URL, "direct.example": config.ProxyDirect}
+		router := newProxyRouter(cfg.Proxy, nil)
+
+		if choice, ok, _ := router.route("https://api.example/"); !ok || choice.state == nil || choice.state.url.String() != proxy.URL {
+			t.Errorf("Expected api.example to use the proxy, but got %v", choice.state)
+		}
+		if choice, ok, _ := router.route("https://direct.example/"); !ok || choice.state != nil {
+			t.Errorf("Expected direct.example to bypass proxies, but got %v", choice.state)
+		}
+		if _, ok, _ := router.route("https://other.org/"); ok {
+			t.Errorf("Expected other hosts to fall back to the environment")
+		}
+	})
+
+	t.Run("Test NO_PROXY bypasses the pool", func(t *testing.T) {
+		t.Setenv("NO_PROXY", "internal.example, 10.0.0.0/8")
+		cfg := config.Defaults()
+		cfg.Proxy.URLs = []string{proxy.URL}
+		router := newProxyRouter(cfg.Proxy, nil)
+
+		for _, target := range []string{"http://internal.example/", "http://api.internal.example/", "http://10.1.2.3/"} {
+			if choice, ok, _ := router.route(target); !ok || choice.state != nil {
+				t.Errorf("Expected %s to bypass the pool, but got %v", target, choice.state)
+			}
+		}
+		if choice, ok, _ := router.route("http://notinternal.example/"); !ok || choice.state == nil {
+			t.Errorf("Expected notinternal.example to use the pool")
+		}
+	})
+
+	t.Run("Test exhausted pool does not count against the host", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Retry = 2
+		cfg.Backoff.BaseDelay = time.Millisecond
+		cfg.Proxy.URLs = []string{dead.URL}
+		cfg.Proxy.MaxFailures = 1
+		cfg.Proxy.EjectFor = time.Minute
+		s := newTestScraper(t, cfg, newMapCache())
+
+		// The first attempt ejects the only proxy, so the retry finds none left.
+		res := s.ScrapeWithRetry(context.Background(), "http://scrape.example/page")
+
+		var scrapeErr *ScrapeError
+		if !errors.As(res.Err, &scrapeErr) || scrapeErr.Kind != ErrorProxy || !errors.Is(res.Err, ErrNoProxy) {
+			t.Fatalf("Expected a proxy error, but got %v", res.Err)
+		}
+		if outcomeOf(res.Err) != outcomeNeutral {
+			t.Errorf("Expected an exhausted pool to be neutral for the circuit breaker")
+		}
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+// rateLimiter throttles requests with a token bucket, a minimum delay and a
+// concurrency cap per host. Limits come from the most specific host glob, or
+// the defaults when none matches.
+type rateLimiter struct {
+	cfg config.RateLimitConfig
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{cfg: cfg, hosts: make(map[string]*hostLimiter)}
+}
+
+// limit returns the limiter of the host of rawURL, creating it on first use.
+func (r *rateLimiter) limit(rawURL string) *hostLimiter {
+	host := hostOf(rawURL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h, ok := r.hosts[host]; ok {
+		return h
+	}
+
+	limit := r.cfg.RateLimit
+	if matches := matchingHostPatterns(slices.Collect(maps.Keys(r.cfg.Hosts)), rawURL); len(matches) > 0 {
+		limit = r.cfg.Hosts[matches[len(matches)-1]]
+	}
+
+	h := newHostLimiter(limit)
+	r.hosts[host] = h
+	return h
+}
+
+// wait blocks until a request to rawURL may start. The returned function must
+// be called once the request has finished.
+func (r *rateLimiter) wait(ctx context.Context, rawURL string) (func(), error) {
+	return r.limit(rawURL).wait(ctx)
+}
+
+// crawlDelay raises the minimum delay between requests to the host of rawURL
+// to at least delay, as asked for by its robots.txt.
+func (r *rateLimiter) crawlDelay(rawURL string, delay time.Duration) {
+	h := r.limit(rawURL)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limit.MinDelay = max(h.limit.MinDelay, delay)
+}
+
+// hostLimiter throttles the requests to one host.
+type hostLimiter struct {
+	limit config.RateLimit
+	slots chan struct{} // nil without a concurrency cap
+
+	mu        sync.Mutex
+	tokens    float64   // may go negative for requests already scheduled
+	refilled  time.Time // when tokens was last brought up to date
+	nextStart time.Time // earliest start allowed by the minimum delay
+}
+
+func newHostLimiter(limit config.RateLimit) *hostLimiter {
+	h := &hostLimiter{limit: limit, tokens: float64(max(limit.Burst, 1))}
+	if limit.MaxConcurrent > 0 {
+		h.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return h
+}
+
+// wait takes a concurrency slot, then sleeps until the rate and minimum delay
+// allow the request to start.
+func (h *hostLimiter) wait(ctx context.Context) (func(), error) {
+	release := func() {}
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+			release = func() { <-h.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	delay := h.reserve(time.Now())
+	if delay <= 0 {
+		return release, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return release, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// reserve books the next start time for a request and returns how long to
+// wait for it.
+func (h *hostLimiter) reserve(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	start := now
+	if rate := h.limit.RequestsPerSecond; rate > 0 {
+		burst := float64(max(h.limit.Burst, 1))
+		if !h.refilled.IsZero() {
+			h.tokens = min(burst, h.tokens+now.Sub(h.refilled).Seconds()*rate)
+		}
+		h.refilled = now
+
+		h.tokens--
+		if h.tokens < 0 {
+			start = now.Add(time.Duration(-h.tokens / rate * float64(time.Second)))
+		}
+	}
+
+	if start.Before(h.nextStart) {
+		start = h.nextStart
+	}
+	if h.limit.MinDelay > 0 {
+		h.nextStart = start.Add(h.limit.MinDelay)
+	}
+
+	return start.Sub(now)
+}
+
+// InterleaveByHost reorders targets so consecutive targets go to different
+// hosts where possible, keeping the order within each host. Workers then spread
+// over every host instead of queueing behind the rate limit of one.
+func InterleaveByHost(targets []Target) []Target {
+	var hosts []string
+	byHost := make(map[string][]Target)
+	for _, target := range targets {
+		host := hostOf(target.URL)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], target)
+	}
+
+	interleaved := make([]Target, 0, len(targets))
+	for len(interleaved) < len(targets) {
+		for _, host := range hosts {
+			if queue := byHost[host]; len(queue) > 0 {
+				interleaved = append(interleaved, queue[0])
+				byHost[host] = queue[1:]
+			}
+		}
+	}
+
+	return interleaved
+}
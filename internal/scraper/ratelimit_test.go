@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestHostLimiter(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Test token bucket", func(t *testing.T) {
+		h := newHostLimiter(config.RateLimit{RequestsPerSecond: 2, Burst: 2})
+
+		var delays []time.Duration
+		for range 4 {
+			delays = append(delays, h.reserve(now))
+		}
+		expected := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+		if fmt.Sprint(delays) != fmt.Sprint(expected) {
+			t.Errorf("Expected delays %v, but got %v", expected, delays)
+		}
+
+		// Three seconds later the bucket has refilled past the scheduled requests.
+		if delay := h.reserve(now.Add(3 * time.Second)); delay != 0 {
+			t.Errorf("Expected no delay after the bucket refilled, but got %v", delay)
+		}
+	})
+
+	t.Run("Test minimum delay", func(t *testing.T) {
+		h := newHostLimiter(config.RateLimit{MinDelay: time.Second})
+
+		if delay := h.reserve(now); delay != 0 {
+			t.Errorf("Expected the first request to start at once, but got %v", delay)
+		}
+		if delay := h.reserve(now.Add(200 * time.Millisecond)); delay != 800*time.Millisecond {
+			t.Errorf("Expected a delay of 800ms, but got %v", delay)
+		}
+		if delay := h.reserve(now.Add(200 * time.Millisecond)); delay != 1800*time.Millisecond {
+			t.Errorf("Expected a delay of 1.8s, but got %v", delay)
+		}
+	})
+
+	t.Run("Test max concurrent", func(t *testing.T) {
+		h := newHostLimiter(config.RateLimit{MaxConcurrent: 1})
+
+		release, err := h.wait(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error waiting: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := h.wait(ctx); err == nil {
+			t.Errorf("Expected a second request to wait for the first")
+		}
+
+		release()
+		if _, err := h.wait(context.Background()); err != nil {
+			t.Errorf("Expected the slot to be free again, but got %v", err)
+		}
+	})
+}
+
+func TestRateLimiterHosts(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{
+		RateLimit: config.RateLimit{RequestsPerSecond: 10},
+		Hosts: map[string]config.RateLimit{
+			"*.example.com": {RequestsPerSecond: 1},
+		},
+	})
+
+	a := limiter.limit("https://a.example.com/1")
+	if a != limiter.limit("https://A.example.com/2") {
+		t.Errorf("Expected requests to the same host to share a limiter")
+	}
+	if a == limiter.limit("https://b.example.com/") {
+		t.Errorf("Expected hosts matching the same glob to be limited separately")
+	}
+	if a.limit.RequestsPerSecond != 1 {
+		t.Errorf("Expected the host override to apply, but got %v", a.limit)
+	}
+	if other := limiter.limit("https://example.org/"); other.limit.RequestsPerSecond != 10 {
+		t.Errorf("Expected the default limit for other hosts, but got %v", other.limit)
+	}
+}
+
+func TestInterleaveByHost(t *testing.T) {
+	var targets []Target
+	for _, url := range []string{"https://a.com/1", "https://a.com/2", "https://a.com/3", "https://b.com/1", "https://c.com/1", "https://b.com/2"} {
+		targets = append(targets, Target{URL: url})
+	}
+
+	var got []string
+	for _, target := range InterleaveByHost(targets) {
+		got = append(got, target.URL)
+	}
+
+	expected := "[https://a.com/1 https://b.com/1 https://c.com/1 https://a.com/2 https://b.com/2 https://a.com/3]"
+	if fmt.Sprint(got) != expected {
+		t.Errorf("Expected %s, but got %v", expected, got)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+// hasValidators reports whether a cached response can be revalidated with a
+// conditional request.
+func hasValidators(record cacheRecord) bool {
+	return record.Header.Get("ETag") != "" || record.Header.Get("Last-Modified") != ""
+}
+
+// conditionalRequest returns a copy of req asking the server to answer with
+// 304 Not Modified if the cached response is still current.
+func conditionalRequest(req Request, cached cacheRecord) Request {
+	req.Revalidating = true
+	req.Header = req.Header.Clone()
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return req
+}
+
+// refreshRecord applies a 304 Not Modified response to the cached response it
+// revalidated: the body is kept and the stored headers are updated with the
+// ones sent along with the 304, as described in RFC 9111 section 4.3.4.
+func refreshRecord(cached, notModified cacheRecord) cacheRecord {
+	refreshed := cacheRecord{Data: cached.Data, Header: cached.Header.Clone(), Body: cached.Body}
+	for name, values := range notModified.Header {
+		if name == "Content-Length" {
+			continue
+		}
+		refreshed.Header[name] = values
+	}
+
+	refreshed.Data.ResponseTime = notModified.Data.ResponseTime
+	refreshed.Data.Timestamp = notModified.Data.Timestamp
+	return refreshed
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/storage"
+	wp "github.com/JesterSe7en/porygo/internal/workerpool"
+)
+
+// robotsKeyPrefix prefixes the cache keys of robots.txt files, followed by the origin.
+const robotsKeyPrefix = "robots/"
+
+// maxRobotsSize caps how much of a robots.txt is read (RFC 9309 requires at least 500 KiB).
+const maxRobotsSize = 512 << 10
+
+// robotsRule is an Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// robotsRules are the rules of a robots.txt that apply to our user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// allowRobots permits everything; it applies when a site has no robots.txt.
+var allowRobots = &robotsRules{}
+
+// errDisallowed ends the attempts to scrape a URL that robots.txt disallows.
+var errDisallowed = errors.New("disallowed by robots.txt")
+
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i, r := range pattern {
+		switch {
+		case r == '*':
+			expr.WriteString(".*")
+		case r == '$' && i == len(pattern)-1:
+			expr.WriteString("$")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return robotsRule{allow: allow, pattern: pattern, re: regexp.MustCompile(expr.String())}
+}
+
+// robotsGroup is a set of rules shared by consecutive User-agent lines.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots returns the rules of a robots.txt that apply to agent: those of
+// every group naming it, or of the "*" groups when none does (RFC 9309).
+func parseRobots(body []byte, agent string) *robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inRules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// An empty Disallow allows everything, which is the default anyway.
+			if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "*") {
+				current.rules = append(current.rules, newRobotsRule(key == "allow", value))
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	agent = strings.ToLower(agent)
+	matches := func(name string) bool {
+		return name == agent || strings.HasPrefix(name, agent+"/")
+	}
+
+	// A group naming the agent is used even when it has no rules.
+	rules := &robotsRules{}
+	for _, pick := range []func(string) bool{matches, func(name string) bool { return name == "*" }} {
+		matched := false
+		for _, group := range groups {
+			for _, name := range group.agents {
+				if pick(name) {
+					matched = true
+					rules.rules = append(rules.rules, group.rules...)
+					rules.crawlDelay = max(rules.crawlDelay, group.crawlDelay)
+					break
+				}
+			}
+		}
+		if matched {
+			break
+		}
+	}
+
+	return rules
+}
+
+// allowed reports whether rawURL may be fetched. The longest matching rule
+// wins, and Allow wins a tie.
+func (r *robotsRules) allowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+
+	target := cmp.Or(u.EscapedPath(), "/")
+	if target == "/robots.txt" {
+		return true
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(target) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+
+	return allow
+}
+
+// robotsEntry holds the rules of one origin once loaded, so concurrent
+// workers fetch each robots.txt only once.
+type robotsEntry struct {
+	ready chan struct{}
+	rules *robotsRules
+	err   error // why robots.txt could not be fetched, nil once rules are set
+}
+
+// robotsCache keeps the robots.txt rules loaded during a run, by origin.
+type robotsCache struct {
+	mu      sync.Mutex
+	origins map[string]*robotsEntry
+}
+
+// checkRobots returns errDisallowed when robots.txt disallows the target, and
+// the ScrapeError of the robots.txt fetch when it cannot be read. Nothing may
+// be fetched from a site before its robots.txt is known (RFC 9309).
+func (s *Scraper) checkRobots(ctx context.Context, rawURL string) error {
+	rules, err := s.robotsFor(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	if !rules.allowed(rawURL) {
+		return errDisallowed
+	}
+	return nil
+}
+
+// skippedResult reports a URL that robots.txt disallows.
+func (s *Scraper) skippedResult(rawURL string) wp.Result {
+	s.log.Info("Skipping %s: disallowed by robots.txt", rawURL)
+	return wp.Result{
+		Value: ScrapedData{URL: rawURL, Timestamp: time.Now(), Skipped: errDisallowed.Error()},
+		Err:   nil,
+	}
+}
+
+// robotsFor returns the robots.txt rules for the origin of rawURL, loading
+// them from the cache or the site on first use. Crawl-delay is applied to the
+// host's rate limit as soon as the rules are known. Failed fetches are not
+// remembered, so the next attempt fetches robots.txt again.
+func (s *Scraper) robotsFor(ctx context.Context, rawURL string) (*robotsRules, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return allowRobots, nil
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	s.robots.mu.Lock()
+	entry, ok := s.robots.origins[origin]
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		s.robots.origins[origin] = entry
+	}
+	s.robots.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.ready:
+			return entry.rules, entry.err
+		case <-ctx.Done():
+			return nil, canceledError(rawURL, ctx.Err())
+		}
+	}
+
+	entry.rules, entry.err = s.loadRobots(ctx, origin)
+	if entry.err != nil {
+		s.robots.mu.Lock()
+		delete(s.robots.origins, origin)
+		s.robots.mu.Unlock()
+	} else if entry.rules.crawlDelay > 0 {
+		s.log.Debug("Honoring Crawl-delay of %v for %s", entry.rules.crawlDelay, origin)
+		s.limit.crawlDelay(rawURL, entry.rules.crawlDelay)
+	}
+	close(entry.ready)
+
+	return entry.rules, entry.err
+}
+
+// loadRobots reads the robots.txt of origin from the cache, fetching and
+// caching it when missing or expired. A missing robots.txt allows everything;
+// an unreachable one is returned as an error.
+func (s *Scraper) loadRobots(ctx context.Context, origin string) (*robotsRules, error) {
+	key := robotsKeyPrefix + origin
+	if record, expiration, ok := s.readCacheRecord(key); ok && time.Now().Before(expiration) {
+		return s.robotsFromRecord(record), nil
+	}
+
+	record, err := s.fetchRobots(ctx, origin)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Warn("Cannot fetch robots.txt of %s: %v", origin, err)
+		}
+		return nil, err
+	}
+
+	s.storeRobots(key, record)
+	return s.robotsFromRecord(record), nil
+}
+
+// robotsFromRecord parses a fetched robots.txt; client errors mean there is none.
+func (s *Scraper) robotsFromRecord(record cacheRecord) *robotsRules {
+	if record.Data.Status >= 400 {
+		return allowRobots
+	}
+	return parseRobots(record.Body, s.cfg.Robots.UserAgent)
+}
+
+// fetchRobots downloads the robots.txt of origin. Failures, server errors and
+// 429 are returned as a ScrapeError; other client errors are returned as
+// records without a body.
+func (s *Scraper) fetchRobots(ctx context.Context, origin string) (cacheRecord, error) {
+	robotsURL := origin + "/robots.txt"
+
+	release, err := s.limit.wait(ctx, robotsURL)
+	if err != nil {
+		return cacheRecord{}, requestError(robotsURL, err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return cacheRecord{}, &ScrapeError{Kind: ErrorParse, URL: robotsURL, Err: err}
+	}
+	req.Header.Set("User-Agent", cmp.Or(s.cfg.Request.UserAgent, defaultUserAgent))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return cacheRecord{}, requestError(robotsURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return cacheRecord{}, statusError(robotsURL, res, time.Now())
+	}
+
+	record := cacheRecord{
+		Data:   ScrapedData{URL: robotsURL, Status: res.StatusCode, Timestamp: time.Now()},
+		Header: res.Header,
+	}
+	if res.StatusCode < 300 {
+		record.Body, err = io.ReadAll(io.LimitReader(res.Body, maxRobotsSize))
+		if err != nil {
+			return cacheRecord{}, err
+		}
+		record.Data.Size = int64(len(record.Body))
+	}
+
+	return record, nil
+}
+
+// storeRobots caches a fetched robots.txt for the configured robots expiration.
+func (s *Scraper) storeRobots(key string, record cacheRecord) {
+	value, err := encodeRecord(record)
+	if err != nil {
+		s.log.Error("Failed to encode %s for cache: %v", record.Data.URL, err)
+		return
+	}
+
+	now := time.Now()
+	err = s.cache.Set(context.Background(), key, storage.CacheEntry{
+		ExpirationTime: now.Add(s.cfg.Robots.Expiration),
+		CreatedAt:      now,
+		Value:          value,
+	})
+	if err != nil && !errors.Is(err, storage.ErrReadOnly) {
+		s.log.Error("Failed to store %s in cache: %v", record.Data.URL, err)
+	}
+}
+
+// newRobotsCache returns an empty robotsCache, or nil when robots.txt is ignored.
+func newRobotsCache(cfg config.RobotsConfig) *robotsCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &robotsCache{origins: make(map[string]*robotsEntry)}
+}
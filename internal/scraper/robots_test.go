@@ -0,0 +1,198 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+	"github.com/JesterSe7en/porygo/internal/storage"
+)
+
+func TestParseRobots(t *testing.T) {
+	robots := []byte(`
+User-agent: *
+Disallow: /
+
+# porygo may crawl everything but the admin pages
+User-agent: Porygo
+User-agent: other-bot
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+Sitemap: https://example.com/sitemap.xml
+`)
+
+	tests := []struct {
+		agent   string
+		url     string
+		allowed bool
+	}{
+		{"porygo", "https://example.com/", true},
+		{"porygo", "https://example.com/admin/users", false},
+		{"porygo", "https://example.com/admin/public/page", true},
+		{"porygo", "https://example.com/files/report.pdf", false},
+		{"porygo", "https://example.com/files/report.pdf?download=1", true},
+		{"porygo", "https://example.com/robots.txt", true},
+		{"unknown-bot", "https://example.com/", false},
+		{"unknown-bot", "https://example.com/robots.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agent+" "+tt.url, func(t *testing.T) {
+			if got := parseRobots(robots, tt.agent).allowed(tt.url); got != tt.allowed {
+				t.Errorf("Expected allowed to be %v, but got %v", tt.allowed, got)
+			}
+		})
+	}
+
+	if delay := parseRobots(robots, "porygo").crawlDelay; delay != 2*time.Second {
+		t.Errorf("Expected a crawl delay of 2s, but got %v", delay)
+	}
+	if delay := parseRobots(robots, "unknown-bot").crawlDelay; delay != 0 {
+		t.Errorf("Expected no crawl delay for other agents, but got %v", delay)
+	}
+
+	t.Run("Test empty group naming the agent", func(t *testing.T) {
+		robots := []byte(`
+User-agent: porygo
+Disallow:
+
+User-agent: *
+Disallow: /
+`)
+		if !parseRobots(robots, "porygo").allowed("https://example.com/page") {
+			t.Errorf("Expected the empty porygo group to allow everything")
+		}
+		if parseRobots(robots, "unknown-bot").allowed("https://example.com/page") {
+			t.Errorf("Expected other agents to fall back to the * group")
+		}
+	})
+}
+
+// newRobotsTestScraper is newTestScraper with robots.txt obeyed.
+func newRobotsTestScraper(t *testing.T, cfg config.Config, cache storage.CacheStorage) *Scraper {
+	t.Helper()
+	log, err := logger.New("", false, false)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	cfg.Robots.Enabled = true
+	s, err := New(&cfg, &log, cache)
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	return s
+}
+
+func TestRobots(t *testing.T) {
+	robotsFetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches++
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\nCrawl-delay: 0.5\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	s := newRobotsTestScraper(t, cfg, cache)
+
+	res := s.ScrapeWithRetry(context.Background(), server.URL+"/private/page")
+	if res.Err != nil {
+		t.Fatalf("Expected a skipped result rather than an error, but got %v", res.Err)
+	}
+	if data := res.Value.(ScrapedData); data.Skipped == "" || data.Status != 0 {
+		t.Errorf("Expected the disallowed URL to be skipped, but got %+v", data)
+	}
+
+	if res := s.ScrapeWithRetry(context.Background(), server.URL+"/public"); res.Err != nil || res.Value.(ScrapedData).Skipped != "" {
+		t.Errorf("Expected the allowed URL to be scraped, but got %+v (%v)", res.Value, res.Err)
+	}
+
+	if delay := s.limit.limit(server.URL).limit.MinDelay; delay != 500*time.Millisecond {
+		t.Errorf("Expected the crawl delay to space requests by 500ms, but got %v", delay)
+	}
+
+	// A new run reads robots.txt from the cache.
+	s = newRobotsTestScraper(t, cfg, cache)
+	s.ScrapeWithRetry(context.Background(), server.URL+"/private/other")
+	if robotsFetches != 1 {
+		t.Errorf("Expected robots.txt to be fetched once, but it was fetched %d times", robotsFetches)
+	}
+}
+
+func TestRobotsUnreachable(t *testing.T) {
+	var robotsStatus, robotsFetches atomic.Int32
+	robotsStatus.Store(http.StatusNotFound)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			w.WriteHeader(int(robotsStatus.Load()))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Hello</h1></body></html>")
+	}))
+	defer server.Close()
+
+	cache := newMapCache()
+	cfg := config.Defaults()
+	cfg.Retry = 2
+	cfg.Backoff.BaseDelay = time.Millisecond
+	cfg.SelectorsConfig.Select = []string{"h1"}
+	cfg.Database.Expiration = -time.Minute
+	// robots.txt is fetched again by every scraper.
+	cfg.Robots.Expiration = 0
+	if res := newRobotsTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL+"/cached"); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	robotsStatus.Store(http.StatusServiceUnavailable)
+
+	t.Run("Test server error fails the scrape", func(t *testing.T) {
+		robotsFetches.Store(0)
+		res := newRobotsTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL+"/page")
+
+		var scrapeErr *ScrapeError
+		if !errors.As(res.Err, &scrapeErr) || scrapeErr.Kind != ErrorServer {
+			t.Fatalf("Expected a server ScrapeError, but got %v (%+v)", res.Err, res.Value)
+		}
+		if robotsFetches.Load() != 2 {
+			t.Errorf("Expected robots.txt to be fetched on every attempt, but it was fetched %d times", robotsFetches.Load())
+		}
+	})
+
+	t.Run("Test stale-if-error", func(t *testing.T) {
+		cfg := cfg
+		cfg.StaleIfError = time.Hour
+		res := newRobotsTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL+"/cached")
+		if res.Err != nil {
+			t.Fatalf("Expected the cached copy to be served, but got %v", res.Err)
+		}
+		if data := res.Value.(ScrapedData); !data.Stale || data.Skipped != "" {
+			t.Errorf("Expected a stale copy, but got %+v", data)
+		}
+	})
+
+	t.Run("Test host down", func(t *testing.T) {
+		server.Close()
+		res := newRobotsTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL+"/page")
+
+		var scrapeErr *ScrapeError
+		if !errors.As(res.Err, &scrapeErr) || scrapeErr.Kind != ErrorNetwork {
+			t.Errorf("Expected a network ScrapeError, but got %v (%+v)", res.Err, res.Value)
+		}
+	})
+}
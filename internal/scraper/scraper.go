@@ -7,14 +7,18 @@ package scraper
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"math"
 	"math/rand"
 	"mime"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,117 +29,325 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
+// defaultUserAgent is sent with every request.
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.110 Safari/537.3"
+
 type Scraper struct {
-	client *http.Client
-	log    *logger.Logger
-	cfg    *config.Config
-	cache  storage.CacheStorage
+	client  *http.Client
+	creds   *credentials
+	jar     *cookieJar // nil unless sessions are enabled
+	limit   *rateLimiter
+	robots  *robotsCache // nil when robots.txt is ignored
+	breaker *circuitBreaker
+	budget  *retryBudget
+	log     *logger.Logger
+	cfg     *config.Config
+	cache   storage.CacheStorage
 }
 
 // TODO: Look into goquery library to parse html better
 
-func New(cfg *config.Config, log *logger.Logger, cache storage.CacheStorage) *Scraper {
-	return &Scraper{
-		client: new(http.Client),
-		log:    log,
-		cfg:    cfg,
-		cache:  cache,
+// New creates a scraper. It fails when the TLS settings cannot be loaded.
+func New(cfg *config.Config, log *logger.Logger, cache storage.CacheStorage) (*Scraper, error) {
+	creds := loadCredentials(cfg.Request, log)
+	transport, err := newTransport(cfg, log, creds)
+	if err != nil {
+		return nil, err
 	}
+	client := &http.Client{Transport: transport}
+
+	s := &Scraper{
+		client:  client,
+		creds:   creds,
+		log:     log,
+		cfg:     cfg,
+		cache:   cache,
+		limit:   newRateLimiter(cfg.RateLimit),
+		robots:  newRobotsCache(cfg.Robots),
+		breaker: newCircuitBreaker(cfg.CircuitBreaker),
+		budget:  newRetryBudget(cfg.Backoff.RetryBudget),
+	}
+
+	if cfg.Session.Enabled() {
+		s.jar = newCookieJar()
+		s.loadCookieFile(s.jar)
+		client.Jar = s.jar
+	}
+
+	return s, nil
+}
+
+// ScrapeWithRetry is the main public function that orchestrates scraping with caching and retry logic.
+// Canceling ctx aborts in-flight requests and retry waits with an ErrorCanceled ScrapeError.
+func (s *Scraper) ScrapeWithRetry(ctx context.Context, url string) wp.Result {
+	return s.ScrapeTarget(ctx, Target{URL: url})
 }
 
-// ScrapeWithRetry is the main public function that orchestrates scraping with caching and retry logic
-func (s *Scraper) ScrapeWithRetry(url string) wp.Result {
-	if !s.cfg.Force {
-		if cached := s.checkCache(url); cached != nil {
+// ScrapeTarget scrapes a target like ScrapeWithRetry, applying its request overrides.
+func (s *Scraper) ScrapeTarget(ctx context.Context, target Target) wp.Result {
+	url := target.URL
+	req := s.newRequest(target)
+
+	fingerprint, err := NewFingerprint(req, s.cfg.SelectorsConfig)
+	if err != nil {
+		s.log.Warn("Cannot fingerprint %s, caching disabled for it: %v", url, err)
+	}
+	cacheable := err == nil
+
+	if s.cfg.Offline {
+		return s.scrapeOffline(url, fingerprint, cacheable)
+	}
+
+	var stale cacheRecord
+	revalidating := false
+	if cacheable && !s.cfg.Force {
+		if cached := s.checkCache(fingerprint.ResultKey()); cached != nil {
 			return *cached
 		}
+		if cached := s.checkResponseCache(fingerprint); cached != nil {
+			return *cached
+		}
+
+		// The conditional headers are volatile, so the fingerprint is unaffected.
+		if stale, revalidating = s.loadRevalidatable(fingerprint.ResponseKey()); revalidating {
+			s.log.Debug("Revalidating cached response for %s", url)
+			req = conditionalRequest(req, stale)
+		}
 	}
 
-	result := s.performScrapeWithRetries(url)
+	resp, err := s.performScrapeWithRetries(ctx, req)
+	if errors.Is(err, errDisallowed) {
+		return s.skippedResult(url)
+	}
+	if err != nil {
+		if cacheable && s.cfg.StaleIfError > 0 && ctx.Err() == nil {
+			if cached := s.staleResult(fingerprint, s.cfg.StaleIfError); cached != nil && cached.Err == nil {
+				s.log.Warn("Failed to scrape %s, serving cached copy: %v", url, err)
+				return *cached
+			}
+		}
 
-	if result.Err != nil {
-		s.log.Error("Failed to scrape %s: %v", url, result.Err)
-		return result
+		s.log.Error("Failed to scrape %s: %v", url, err)
+		return wp.Result{Value: nil, Err: err}
 	}
 
-	var data []byte
-	switch v := result.Value.(type) {
-	case []byte:
-		data = v
-	case string:
-		data = []byte(v)
-	default:
-		s.log.Warn("Unsupported result type for %s: %T", url, v)
+	if revalidating && resp.Data.Status == http.StatusNotModified {
+		s.log.Info("%s not modified, refreshing cached response", url)
+		resp = refreshRecord(stale, resp)
 	}
 
-	if len(data) > 0 {
-		s.storeCacheResult(url, data)
+	if cacheable {
+		s.storeCacheRecord(fingerprint.ResponseKey(), resp)
 	}
 
-	return result
+	return s.extractAndStore(fingerprint, resp, cacheable)
 }
 
-// performScrapeWithRetries handles the retry logic for scraping
-func (s *Scraper) performScrapeWithRetries(url string) wp.Result {
+// newRequest builds the request issued for a target. Headers are layered from
+// the default User-Agent, the configured user_agent and headers, the headers of
+// every matching host from least to most specific, then the target's own. The
+// method defaults to GET, or POST when there is a body.
+func (s *Scraper) newRequest(target Target) Request {
+	url := target.URL
+	reqCfg := s.cfg.Request
+
+	header := make(http.Header)
+	header.Set("User-Agent", defaultUserAgent)
+	if reqCfg.UserAgent != "" {
+		header.Set("User-Agent", reqCfg.UserAgent)
+	}
+
+	for name, value := range reqCfg.Headers {
+		header.Set(name, value)
+	}
+
+	for _, pattern := range matchingHostPatterns(slices.Collect(maps.Keys(reqCfg.Hosts)), url) {
+		for name, value := range reqCfg.Hosts[pattern].Headers {
+			header.Set(name, value)
+		}
+	}
+
+	if len(reqCfg.Cookies) > 0 {
+		header.Add("Cookie", strings.Join(reqCfg.Cookies, "; "))
+	}
+
+	body, ok := target.body()
+	if !ok && reqCfg.Body != "" {
+		body = []byte(reqCfg.Body)
+	}
+	if len(target.JSON) > 0 {
+		header.Set("Content-Type", "application/json")
+	}
+
+	for name, value := range target.Headers {
+		header.Set(name, value)
+	}
+
+	if len(body) > 0 && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	method := cmp.Or(target.Method, reqCfg.Method)
+	if method == "" {
+		method = http.MethodGet
+		if len(body) > 0 {
+			method = http.MethodPost
+		}
+	}
+
+	req := Request{
+		URL:    url,
+		Method: strings.ToUpper(method),
+		Header: header,
+		Body:   body,
+	}
+
+	// An explicit Authorization header is already part of the fingerprint and
+	// keeps the transport from adding credentials.
+	var identity []string
+	if id := s.creds.identity(url); id != "" && header.Get("Authorization") == "" {
+		identity = append(identity, "auth:"+id)
+	}
+	if id := s.jar.identity(url); id != "" {
+		identity = append(identity, "cookies:"+id)
+	}
+	req.Identity = strings.Join(identity, " ")
+
+	return req
+}
+
+// performScrapeWithRetries handles the retry logic for scraping. Failures that
+// cannot succeed on retry, like a 404 or an invalid URL, are returned at once.
+func (s *Scraper) performScrapeWithRetries(ctx context.Context, req Request) (cacheRecord, error) {
 	var lastErr error
+	url := req.URL
 
 	s.log.Debug("Starting scrape retry loop for URL %s with %d retries.", url, s.cfg.Retry)
 
+	host := hostOf(url)
 	for attempt := 1; attempt <= s.cfg.Retry; attempt++ {
+		// Earlier attempts were logged already, so the open circuit is reported alone.
+		if !s.breaker.allow(host, time.Now()) {
+			return cacheRecord{}, &ScrapeError{Kind: ErrorCircuitOpen, URL: url, Err: ErrCircuitOpen}
+		}
+
+		// Only retries that are actually sent spend the budget.
+		if attempt > 1 && !s.budget.take() {
+			// Hand back the probe allow may have granted.
+			s.breaker.record(host, outcomeNeutral, time.Now())
+			s.log.Warn("Retry budget exhausted, not retrying %s", url)
+			return cacheRecord{}, fmt.Errorf("retry budget exhausted: %w", lastErr)
+		}
+
 		s.log.Info("Attempting to scrape URL %s (attempt %d of %d)", url, attempt, s.cfg.Retry)
 
-		result := s.scrape(url)
-		if result.Err == nil {
+		resp, err := s.attempt(ctx, req)
+		if s.breaker.record(host, outcomeOf(err), time.Now()) {
+			s.log.Warn("Opening circuit for %s for %v after %d consecutive failures", host, s.cfg.CircuitBreaker.Cooldown, s.cfg.CircuitBreaker.Threshold)
+		}
+		if err == nil {
 			s.log.Info("Successfully scraped URL %s.", url)
-			return result
+			return resp, nil
+		}
+
+		if errors.Is(err, errDisallowed) {
+			return cacheRecord{}, err
 		}
 
-		lastErr = result.Err
+		lastErr = err
 		// Don't print out the stack trace
-		s.log.Warn("Scraping attempt %d for URL %s failed: %s", attempt, url, result.Err.Error())
+		s.log.Warn("Scraping attempt %d for URL %s failed: %s", attempt, url, err.Error())
+
+		var scrapeErr *ScrapeError
+		if errors.As(err, &scrapeErr) && !scrapeErr.retryable(s.cfg.Backoff.RetryStatuses) {
+			return cacheRecord{}, err
+		}
 
 		// Wait before retry (except for last attempt)
 		if attempt < s.cfg.Retry {
-			delay := s.calculateBackoffDelay(attempt - 1)
+			delay := s.retryDelay(attempt-1, scrapeErr)
 			s.log.Info("Waiting %v before the next retry.", delay)
-			time.Sleep(delay)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return cacheRecord{}, canceledError(url, ctx.Err())
+			}
 		}
 	}
 
-	return wp.Result{
-		Value: nil,
-		Err:   fmt.Errorf("all attempts failed: %s", lastErr.Error()),
+	return cacheRecord{}, fmt.Errorf("all attempts failed: %w", lastErr)
+}
+
+// retryDelay returns how long to wait before the next attempt: the backoff
+// delay, or the wait asked for by Retry-After when longer, capped at
+// max_retry_after.
+func (s *Scraper) retryDelay(attempt int, err *ScrapeError) time.Duration {
+	delay := s.calculateBackoffDelay(attempt)
+
+	if err != nil && err.RetryAfter > delay && s.cfg.Backoff.MaxRetryAfter > 0 {
+		delay = min(err.RetryAfter, max(s.cfg.Backoff.MaxRetryAfter, delay))
 	}
+
+	return delay
 }
 
-// scrape performs the actual HTTP request and returns the result
-func (s *Scraper) scrape(url string) wp.Result {
-	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+// attempt makes sure robots.txt allows req, reading it first when needed, and
+// scrapes it. A robots.txt that cannot be read fails the attempt like the
+// request itself failing would.
+func (s *Scraper) attempt(ctx context.Context, req Request) (cacheRecord, error) {
+	if s.robots != nil {
+		if err := s.checkRobots(ctx, req.URL); err != nil {
+			return cacheRecord{}, err
+		}
+	}
+	return s.scrape(ctx, req)
+}
+
+// scrape performs the actual HTTP request and returns the raw response as a
+// cacheRecord holding its metadata, headers and body.
+func (s *Scraper) scrape(ctx context.Context, request Request) (cacheRecord, error) {
+	// Waiting for the rate limit does not count against the request timeout.
+	release, err := s.limit.wait(ctx, request.URL)
+	if err != nil {
+		return cacheRecord{}, requestError(request.URL, err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
 	defer cancel()
 
 	start := time.Now()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.URL, bytes.NewReader(request.Body))
 	if err != nil {
-		return wp.Result{Value: nil, Err: err}
+		return cacheRecord{}, &ScrapeError{Kind: ErrorParse, URL: request.URL, Err: err}
+	}
+	if (req.URL.Scheme != "http" && req.URL.Scheme != "https") || req.URL.Host == "" {
+		err := fmt.Errorf("%q is not an absolute http(s) URL", request.URL)
+		return cacheRecord{}, &ScrapeError{Kind: ErrorParse, URL: request.URL, Err: err}
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.110 Safari/537.3")
+	req.Header = request.Header.Clone()
 
 	res, err := s.client.Do(req)
 	if err != nil {
-		return wp.Result{Value: nil, Err: err}
+		return cacheRecord{}, requestError(request.URL, err)
 	}
 	defer res.Body.Close()
 
 	finished := time.Now()
 	elapsed := finished.Sub(start)
 
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return wp.Result{Value: nil, Err: fmt.Errorf("request failed with status code: %d", res.StatusCode)}
+	// A 304 is only an answer when there is a cached response for it to refresh.
+	notModified := res.StatusCode == http.StatusNotModified && request.Revalidating
+	if (res.StatusCode < 200 || res.StatusCode >= 300) && !notModified {
+		return cacheRecord{}, statusError(request.URL, res, finished)
 	}
 
 	data := ScrapedData{
-		URL:          url,
+		URL:          request.URL,
 		Status:       res.StatusCode,
 		Title:        res.Header.Get("Title"),
 		ContentType:  res.Header.Get("Content-Type"),
@@ -146,14 +358,43 @@ func (s *Scraper) scrape(url string) wp.Result {
 
 	body, readErr := io.ReadAll(res.Body)
 	if readErr != nil {
-		return wp.Result{Value: nil, Err: readErr}
+		return cacheRecord{}, requestError(request.URL, readErr)
 	}
 
-	if err := s.processBody(&data, body); err != nil {
+	return cacheRecord{Data: data, Header: res.Header, Body: body}, nil
+}
+
+// extract runs the configured selectors and patterns against a raw response.
+func (s *Scraper) extract(resp cacheRecord) (cacheRecord, error) {
+	result := cacheRecord{Data: resp.Data, Header: resp.Header}
+	if err := s.processBody(&result.Data, resp.Body); err != nil {
+		return cacheRecord{}, &ScrapeError{Kind: ErrorParse, URL: resp.Data.URL, Err: err}
+	}
+	return result, nil
+}
+
+// extractAndStore extracts resp and, when cacheable, stores the result under the fingerprint.
+func (s *Scraper) extractAndStore(fingerprint Fingerprint, resp cacheRecord, cacheable bool) wp.Result {
+	result, err := s.extract(resp)
+	if err != nil {
+		s.log.Error("Failed to extract data from %s: %v", resp.Data.URL, err)
 		return wp.Result{Value: nil, Err: err}
 	}
 
-	return wp.Result{Value: data, Err: nil}
+	if cacheable {
+		s.storeCacheRecord(fingerprint.ResultKey(), result)
+	}
+
+	return wp.Result{Value: s.present(result), Err: nil}
+}
+
+// present converts a record into the ScrapedData handed to the presenters.
+func (s *Scraper) present(record cacheRecord) ScrapedData {
+	data := record.Data
+	if s.cfg.Headers {
+		data.Headers = record.Header
+	}
+	return data
 }
 
 func (s *Scraper) processBody(data *ScrapedData, body []byte) error {
@@ -277,54 +518,3 @@ func (s *Scraper) calculateBackoffDelay(attempt int) time.Duration {
 
 	return time.Duration(delay)
 }
-
-// checkCache retrieves and validates cached data for the given URL
-func (s *Scraper) checkCache(url string) *wp.Result {
-	cached, err := s.cache.Get(context.Background(), url)
-
-	if err != nil && err != storage.ErrNotFound {
-		s.log.Error("Failed to retrieve %s from cache: %v", url, err)
-		return nil
-	}
-
-	if err == storage.ErrNotFound {
-		return nil
-	}
-
-	if time.Now().After(cached.ExpirationTime) {
-		s.cleanupExpiredCache(url)
-		return nil
-	}
-
-	// Return valid cached result
-	s.log.Debug("Using cached data for %s, not expired yet", url)
-	return &wp.Result{
-		Value: cached,
-		Err:   nil,
-	}
-}
-
-// storeCacheResult stores the scraped result in the cache
-func (s *Scraper) storeCacheResult(url string, data []byte) {
-	s.log.Debug("Adding %s to cache...", url)
-
-	entry := storage.CacheEntry{
-		ExpirationTime: time.Now().Add(s.cfg.Database.Expiration),
-		Value:          data,
-	}
-
-	err := s.cache.Set(context.Background(), url, entry)
-	if err != nil {
-		s.log.Error("Failed to store %s in cache: %v", url, err)
-	}
-
-	s.log.Debug("Cache put operation successful.")
-}
-
-// cleanupExpiredCache removes expired cache entries
-func (s *Scraper) cleanupExpiredCache(url string) {
-	s.log.Debug("Cached data for %s is old, discarding...", url)
-	if err := s.cache.Delete(context.Background(), url); err != nil {
-		s.log.Error("Failed to delete %s from cache: %v", url, err)
-	}
-}
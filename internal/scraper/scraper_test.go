@@ -1,7 +1,14 @@
 package scraper
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/JesterSe7en/porygo/config"
 )
 
 func TestApplySelectors(t *testing.T) {
@@ -162,3 +169,117 @@ func TestApplyRegexPatterns(t *testing.T) {
 		}
 	})
 }
+
+func TestNewRequest(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Request = config.RequestConfig{
+		UserAgent: "porygo-test",
+		Headers:   map[string]string{"accept": "text/html", "X-Api-Key": "global"},
+		Cookies:   []string{"session=abc", "theme=dark"},
+		Hosts: map[string]config.HostRequestConfig{
+			"*.example.com":   {Headers: map[string]string{"X-Api-Key": "glob", "Accept": "application/json"}},
+			"api.example.com": {Headers: map[string]string{"X-Api-Key": "exact"}},
+		},
+	}
+	s := newTestScraper(t, cfg, newMapCache())
+
+	tests := []struct {
+		url    string
+		accept string
+		apiKey string
+	}{
+		{"https://example.org/", "text/html", "global"},
+		{"https://www.example.com/", "application/json", "glob"},
+		{"https://API.example.com/v1", "application/json", "exact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := s.newRequest(Target{URL: tt.url})
+			if got := req.Header.Get("Accept"); got != tt.accept {
+				t.Errorf("Expected Accept %q, but got %q", tt.accept, got)
+			}
+			if got := req.Header.Get("X-Api-Key"); got != tt.apiKey {
+				t.Errorf("Expected X-Api-Key %q, but got %q", tt.apiKey, got)
+			}
+			if got := req.Header.Get("User-Agent"); got != "porygo-test" {
+				t.Errorf("Expected User-Agent %q, but got %q", "porygo-test", got)
+			}
+			if got := req.Header.Get("Cookie"); got != "session=abc; theme=dark" {
+				t.Errorf("Expected Cookie %q, but got %q", "session=abc; theme=dark", got)
+			}
+		})
+	}
+
+	t.Run("Test default User-Agent", func(t *testing.T) {
+		s := newTestScraper(t, config.Defaults(), newMapCache())
+		if got := s.newRequest(Target{URL: "https://example.org/"}).Header.Get("User-Agent"); got != defaultUserAgent {
+			t.Errorf("Expected the default User-Agent, but got %q", got)
+		}
+	})
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Target
+		wantErr bool
+	}{
+		{line: " https://example.com ", want: Target{URL: "https://example.com"}},
+		{line: `{"url": "https://example.com/search", "method": "post", "body": "q=go"}`, want: Target{URL: "https://example.com/search", Method: "post", Body: "q=go"}},
+		{line: `{"url": "https://example.com", "body": "a", "json": {}}`, wantErr: true},
+		{line: `{"method": "POST"}`, wantErr: true},
+		{line: `{"url": "https://example.com", "unknown": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := ParseTarget(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, but got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.URL != tt.want.URL || got.Method != tt.want.Method || got.Body != tt.want.Body {
+				t.Errorf("Expected %+v, but got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	var method, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, contentType, body = r.Method, r.Header.Get("Content-Type"), string(data)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	cfg := config.Defaults()
+	cfg.Request.Body = "q=go"
+	s := newTestScraper(t, cfg, newMapCache())
+
+	if res := s.ScrapeWithRetry(context.Background(), server.URL); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	if method != http.MethodPost || body != "q=go" || contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Expected a form POST with q=go, but got %s %q (%s)", method, body, contentType)
+	}
+
+	target, err := ParseTarget(`{"url": "` + server.URL + `", "method": "PUT", "json": {"query": "{ items }"}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing target: %v", err)
+	}
+	if res := s.ScrapeTarget(context.Background(), target); res.Err != nil {
+		t.Fatalf("Unexpected error scraping: %v", res.Err)
+	}
+	if method != http.MethodPut || body != `{"query":"{ items }"}` || contentType != "application/json" {
+		t.Errorf("Expected a JSON PUT, but got %s %q (%s)", method, body, contentType)
+	}
+}
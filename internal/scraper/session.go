@@ -0,0 +1,116 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cookieFileMode keeps saved sessions private to the user.
+const cookieFileMode = 0o600
+
+// loadCookieFile fills jar from the configured cookie file. A missing file is
+// not an error: it is created when the session is saved.
+func (s *Scraper) loadCookieFile(jar *cookieJar) {
+	name := s.cfg.Session.CookieFile
+	if name == "" {
+		return
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		s.log.Warn("Cannot read cookie file %s: %v", name, err)
+		return
+	}
+	defer file.Close()
+
+	if err := jar.load(file); err != nil {
+		s.log.Warn("Cannot read cookie file %s: %v", name, err)
+	}
+}
+
+// SaveSession writes the session cookies to the configured cookie file, if any.
+func (s *Scraper) SaveSession() error {
+	name := s.cfg.Session.CookieFile
+	if s.jar == nil || name == "" {
+		return nil
+	}
+
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("failed to create cookie file directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, cookieFileMode)
+	if err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+
+	if err := s.jar.save(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Login submits the configured login form so the session cookies it sets are
+// sent with every later request. It does nothing when no login is configured.
+func (s *Scraper) Login(ctx context.Context) error {
+	login := s.cfg.Session.Login
+	if login.URL == "" {
+		return nil
+	}
+
+	form := make(url.Values)
+	for field, value := range login.Form {
+		form.Set(field, value)
+	}
+	for field, env := range login.FormEnv {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return fmt.Errorf("login field %s: environment variable %s is not set", field, env)
+		}
+		form.Set(field, value)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	method := strings.ToUpper(cmp.Or(login.Method, http.MethodPost))
+	req, err := http.NewRequestWithContext(ctx, method, login.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", cmp.Or(s.cfg.Request.UserAgent, defaultUserAgent))
+
+	s.log.Info("Logging in at %s", login.URL)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("login failed with status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestCookieFile(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	input := fmt.Sprintf("# Netscape HTTP Cookie File\n"+
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\tabc\n"+
+		"#HttpOnly_example.com\tFALSE\t/app\tTRUE\t%d\ttoken\txyz\n"+
+		"example.com\tFALSE\t/\tFALSE\t1\texpired\told\n", expires)
+
+	jar := newCookieJar()
+	if err := jar.load(strings.NewReader(input)); err != nil {
+		t.Fatalf("Unexpected error loading cookies: %v", err)
+	}
+
+	cookies := jar.Cookies(&url.URL{Scheme: "https", Host: "example.com", Path: "/app/page"})
+	if len(cookies) != 2 {
+		t.Fatalf("Expected 2 cookies for /app/page, but got %v", cookies)
+	}
+	if cookies := jar.Cookies(&url.URL{Scheme: "http", Host: "example.com", Path: "/app"}); len(cookies) != 1 {
+		t.Errorf("Expected the secure cookie to be withheld over http, but got %v", cookies)
+	}
+
+	var out strings.Builder
+	if err := jar.save(&out); err != nil {
+		t.Fatalf("Unexpected error saving cookies: %v", err)
+	}
+
+	expected := fmt.Sprintf("# Netscape HTTP Cookie File\n"+
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\tabc\n"+
+		"#HttpOnly_example.com\tFALSE\t/app\tTRUE\t%d\ttoken\txyz\n", expires)
+	if out.String() != expected {
+		t.Errorf("Expected saved cookies\n%s\nbut got\n%s", expected, out.String())
+	}
+
+	t.Run("Test cookies for other domains are not saved", func(t *testing.T) {
+		jar := newCookieJar()
+		attacker := &url.URL{Scheme: "https", Host: "attacker.example.org", Path: "/"}
+		jar.SetCookies(attacker, []*http.Cookie{
+			{Name: "sid", Value: "evil", Domain: "bank.com", Path: "/"},
+			{Name: "theme", Value: "dark", Domain: ".example.org", Path: "/"},
+		})
+
+		var out strings.Builder
+		if err := jar.save(&out); err != nil {
+			t.Fatalf("Unexpected error saving cookies: %v", err)
+		}
+
+		reloaded := newCookieJar()
+		if err := reloaded.load(strings.NewReader(out.String())); err != nil {
+			t.Fatalf("Unexpected error loading cookies: %v", err)
+		}
+		if cookies := reloaded.Cookies(&url.URL{Scheme: "https", Host: "bank.com", Path: "/"}); len(cookies) != 0 {
+			t.Errorf("Expected no cookies for bank.com, but got %v", cookies)
+		}
+		if cookies := reloaded.Cookies(&url.URL{Scheme: "https", Host: "www.example.org", Path: "/"}); len(cookies) != 1 {
+			t.Errorf("Expected the parent domain cookie to be kept, but got %v", cookies)
+		}
+	})
+
+	t.Run("Test malformed line", func(t *testing.T) {
+		if err := newCookieJar().load(strings.NewReader("example.com\tFALSE\t/\n")); err == nil {
+			t.Errorf("Expected an error for a line with missing fields")
+		}
+	})
+}
+
+func TestLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			r.ParseForm()
+			if r.Form.Get("user") != "alice" || r.Form.Get("password") != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "42", Path: "/"})
+			return
+		}
+
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "42" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "members only")
+	}))
+	defer server.Close()
+
+	t.Setenv("PORYGO_TEST_PASSWORD", "s3cret")
+	cookieFile := filepath.Join(t.TempDir(), "cookies.txt")
+	cfg := config.Defaults()
+	cfg.Retry = 1
+	cfg.Session = config.SessionConfig{
+		CookieFile: cookieFile,
+		Login: config.LoginConfig{
+			URL:     server.URL + "/login",
+			Form:    map[string]string{"user": "alice"},
+			FormEnv: map[string]string{"password": "PORYGO_TEST_PASSWORD"},
+		},
+	}
+	s := newTestScraper(t, cfg, newMapCache())
+
+	if res := s.ScrapeWithRetry(context.Background(), server.URL+"/private"); res.Err == nil {
+		t.Fatalf("Expected scraping without a session to fail")
+	}
+
+	if err := s.Login(context.Background()); err != nil {
+		t.Fatalf("Unexpected error logging in: %v", err)
+	}
+	if res := s.ScrapeWithRetry(context.Background(), server.URL+"/private"); res.Err != nil {
+		t.Fatalf("Unexpected error scraping with a session: %v", res.Err)
+	}
+
+	if err := s.SaveSession(); err != nil {
+		t.Fatalf("Unexpected error saving the session: %v", err)
+	}
+	saved, err := os.ReadFile(cookieFile)
+	if err != nil || !strings.Contains(string(saved), "\tsession\t42\n") {
+		t.Fatalf("Expected the session cookie to be saved, but got %q (%v)", saved, err)
+	}
+
+	t.Run("Test session restored from file", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Retry = 1
+		cfg.Force = true
+		cfg.Session.CookieFile = cookieFile
+		s := newTestScraper(t, cfg, newMapCache())
+		if res := s.ScrapeWithRetry(context.Background(), server.URL+"/private"); res.Err != nil {
+			t.Errorf("Unexpected error scraping with a restored session: %v", res.Err)
+		}
+	})
+
+	t.Run("Test rejected login", func(t *testing.T) {
+		t.Setenv("PORYGO_TEST_PASSWORD", "wrong")
+		if err := s.Login(context.Background()); err == nil {
+			t.Errorf("Expected an error for a rejected login")
+		}
+	})
+}
+
+func TestSessionCacheIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if cookie, err := r.Cookie("session"); err == nil {
+			fmt.Fprintf(w, "<html><body><h1>Member %s</h1></body></html>", cookie.Value)
+			return
+		}
+		fmt.Fprint(w, "<html><body><h1>Guest</h1></body></html>")
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	host := u.Hostname()
+	cookieFile := filepath.Join(t.TempDir(), "cookies.txt")
+
+	cache := newMapCache()
+	scrape := func(session string) string {
+		t.Helper()
+		cfg := config.Defaults()
+		cfg.SelectorsConfig.Select = []string{"h1"}
+		if session != "" {
+			line := fmt.Sprintf("%s\tFALSE\t/\tFALSE\t0\tsession\t%s\n", host, session)
+			if err := os.WriteFile(cookieFile, []byte(line), 0o600); err != nil {
+				t.Fatalf("Failed to write cookie file: %v", err)
+			}
+			cfg.Session.CookieFile = cookieFile
+		}
+
+		res := newTestScraper(t, cfg, cache).ScrapeWithRetry(context.Background(), server.URL)
+		if res.Err != nil {
+			t.Fatalf("Unexpected error scraping: %v", res.Err)
+		}
+		return res.Value.(ScrapedData).Extracted["h1"][0]
+	}
+
+	if got := scrape(""); got != "Guest" {
+		t.Fatalf("Expected the guest page, but got %q", got)
+	}
+	if got := scrape("42"); got != "Member 42" {
+		t.Errorf("Expected the guest page not to be served to a session, but got %q", got)
+	}
+	if got := scrape("43"); got != "Member 43" {
+		t.Errorf("Expected another session not to get the cached page, but got %q", got)
+	}
+	if got := scrape(""); got != "Guest" {
+		t.Errorf("Expected a session's page not to be served without one, but got %q", got)
+	}
+}
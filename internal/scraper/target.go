@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Target is a URL to scrape together with request settings overriding the
+// configured ones for this URL alone.
+type Target struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	JSON    json.RawMessage   `json:"json,omitempty"` // sent as the body with Content-Type application/json
+}
+
+// ParseTarget parses a line of scraper input: either a plain URL or a JSON
+// object such as {"url": "...", "method": "POST", "json": {"q": "go"}}.
+func ParseTarget(line string) (Target, error) {
+	line = strings.TrimSpace(line)
+
+	var target Target
+	if strings.HasPrefix(line, "{") {
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&target); err != nil {
+			return Target{}, fmt.Errorf("invalid target %s: %w", line, err)
+		}
+	} else {
+		target.URL = line
+	}
+
+	if target.URL == "" {
+		return Target{}, fmt.Errorf("invalid target %s: missing url", line)
+	}
+	if _, err := url.Parse(target.URL); err != nil {
+		return Target{}, fmt.Errorf("invalid URL: %s", target.URL)
+	}
+	if target.Body != "" && len(target.JSON) > 0 {
+		return Target{}, fmt.Errorf("invalid target %s: body and json cannot be used together", line)
+	}
+
+	return target, nil
+}
+
+// body returns the request body of the target and whether it set one.
+func (t Target) body() ([]byte, bool) {
+	if len(t.JSON) > 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, t.JSON); err == nil {
+			return compact.Bytes(), true
+		}
+		return t.JSON, true
+	}
+	if t.Body != "" {
+		return []byte(t.Body), true
+	}
+	return nil, false
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+// newTLSConfig builds the TLS settings of the transport. It returns nil when
+// the Go defaults apply.
+func newTLSConfig(cfg config.TLSConfig, log *logger.Logger) (*tls.Config, error) {
+	if cfg == (config.TLSConfig{}) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: config.TLSVersions[cfg.MinVersion]}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to read CA bundle: no PEM certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.Insecure {
+		log.Warn("TLS certificate verification is DISABLED: responses may come from anyone able to intercept the connection")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
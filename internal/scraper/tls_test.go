@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+func TestTLSConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "secure")
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tls  config.TLSConfig
+		ok   bool
+	}{
+		{"Test untrusted certificate", config.TLSConfig{}, false},
+		{"Test CA bundle", config.TLSConfig{CAFile: caFile}, true},
+		{"Test insecure", config.TLSConfig{Insecure: true}, true},
+		{"Test minimum version", config.TLSConfig{CAFile: caFile, MinVersion: "1.3"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Defaults()
+			cfg.Retry = 1
+			cfg.TLS = tt.tls
+			s := newTestScraper(t, cfg, newMapCache())
+
+			res := s.ScrapeWithRetry(context.Background(), server.URL)
+			if ok := res.Err == nil; ok != tt.ok {
+				t.Errorf("Expected success %v, but got error %v", tt.ok, res.Err)
+			}
+		})
+	}
+
+	t.Run("Test unreadable client certificate", func(t *testing.T) {
+		log, err := logger.New("", false, false)
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+
+		cfg := config.Defaults()
+		cfg.TLS = config.TLSConfig{CertFile: caFile, KeyFile: caFile}
+		if _, err := New(&cfg, &log, newMapCache()); err == nil {
+			t.Errorf("Expected an error for a client certificate without a key")
+		}
+	})
+}
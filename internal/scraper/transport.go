@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"github.com/JesterSe7en/porygo/internal/logger"
+)
+
+// newTransport builds the round tripper shared by every request of a scraper.
+// The base http.Transport is wrapped for proxy routing, then for credentials,
+// so both apply again to every redirect.
+func newTransport(cfg *config.Config, log *logger.Logger, creds *credentials) (http.RoundTripper, error) {
+	base := newBaseTransport(cfg)
+
+	tlsConfig, err := newTLSConfig(cfg.TLS, log)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		base.TLSClientConfig = tlsConfig
+	}
+
+	var transport http.RoundTripper = base
+	if router := newProxyRouter(cfg.Proxy, log); router != nil {
+		transport = &proxyTransport{base: transport, router: router}
+	}
+
+	if !creds.empty() {
+		transport = &authTransport{base: transport, creds: creds}
+	}
+
+	return transport, nil
+}
+
+// newBaseTransport returns an http.Transport tuned for the configured
+// concurrency. The default of 2 idle connections per host would force most
+// workers to reconnect on every request in a run against a single host.
+func newBaseTransport(cfg *config.Config) *http.Transport {
+	tc := cfg.Transport
+
+	maxIdlePerHost := tc.MaxIdleConnsPerHost
+	if maxIdlePerHost == 0 {
+		maxIdlePerHost = max(cfg.Concurrency, http.DefaultMaxIdleConnsPerHost)
+	}
+
+	maxIdle := tc.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = max(100, maxIdlePerHost)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   tc.DialTimeout,
+		KeepAlive: tc.KeepAlive,
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(tc.HTTP2)
+
+	return &http.Transport{
+		Proxy:                 proxyFromContext,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdlePerHost,
+		MaxConnsPerHost:       tc.MaxConnsPerHost,
+		IdleConnTimeout:       tc.IdleConnTimeout,
+		TLSHandshakeTimeout:   tc.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: tc.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		Protocols:             protocols,
+	}
+}
@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestBaseTransport(t *testing.T) {
+	t.Run("Test limits derived from concurrency", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Concurrency = 50
+		transport := newBaseTransport(&cfg)
+
+		if transport.MaxIdleConnsPerHost != 50 {
+			t.Errorf("Expected 50 idle connections per host, but got %d", transport.MaxIdleConnsPerHost)
+		}
+		if transport.MaxIdleConns != 100 {
+			t.Errorf("Expected 100 idle connections, but got %d", transport.MaxIdleConns)
+		}
+		if !transport.Protocols.HTTP2() {
+			t.Errorf("Expected HTTP/2 to be enabled by default")
+		}
+	})
+
+	t.Run("Test configured limits", func(t *testing.T) {
+		cfg := config.Defaults()
+		cfg.Concurrency = 200
+		cfg.Transport.MaxIdleConnsPerHost = 20
+		cfg.Transport.MaxConnsPerHost = 40
+		cfg.Transport.ResponseHeaderTimeout = 5 * time.Second
+		cfg.Transport.HTTP2 = false
+		transport := newBaseTransport(&cfg)
+
+		if transport.MaxIdleConnsPerHost != 20 || transport.MaxConnsPerHost != 40 {
+			t.Errorf("Expected the configured per-host limits, but got %d idle and %d open",
+				transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+		}
+		if transport.ResponseHeaderTimeout != 5*time.Second {
+			t.Errorf("Expected a 5s response header timeout, but got %v", transport.ResponseHeaderTimeout)
+		}
+		if transport.Protocols.HTTP2() || !transport.Protocols.HTTP1() {
+			t.Errorf("Expected only HTTP/1 with http2 disabled")
+		}
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package scraper
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of config.Database.TTLMode
+const (
+	TTLModeFixed = "fixed" // every entry lives for database.expiration
+	TTLModeHTTP  = "http"  // lifetimes follow Cache-Control and Expires
+)
+
+// entryTTL returns how long record may be cached, and false if it must not be
+// cached at all. A per-host override wins over everything else; in http mode the
+// response headers decide, bounded by min_ttl and max_ttl, and responses without
+// caching headers fall back to database.expiration.
+func (s *Scraper) entryTTL(record cacheRecord, now time.Time) (time.Duration, bool) {
+	db := s.cfg.Database
+
+	httpMode := strings.EqualFold(db.TTLMode, TTLModeHTTP)
+	ttl, cacheable, explicit := db.Expiration, true, false
+	if httpMode {
+		ttl, cacheable, explicit = headerTTL(record.Header, now)
+		if !explicit {
+			ttl = db.Expiration
+		}
+	}
+
+	if !cacheable {
+		return 0, false
+	}
+
+	if override, ok := hostTTL(db.HostTTL, record.Data.URL); ok {
+		return override, true
+	}
+
+	if explicit {
+		if db.MinTTL > 0 && ttl < db.MinTTL {
+			ttl = db.MinTTL
+		}
+		if db.MaxTTL > 0 && ttl > db.MaxTTL {
+			ttl = db.MaxTTL
+		}
+	}
+
+	return ttl, true
+}
+
+// headerTTL derives a lifetime from the Cache-Control and Expires headers of a
+// response. It reports whether the response may be stored and whether the
+// headers specified a lifetime at all.
+func headerTTL(header http.Header, now time.Time) (ttl time.Duration, cacheable bool, explicit bool) {
+	maxAge := -1
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store":
+				return 0, false, true
+			case "no-cache":
+				// Stored, but has to be revalidated before every use.
+				return 0, true, true
+			case "max-age":
+				if seconds, err := strconv.Atoi(strings.Trim(arg, `"`)); err == nil && seconds >= 0 {
+					maxAge = seconds
+				}
+			}
+		}
+	}
+
+	if maxAge >= 0 {
+		ttl = time.Duration(maxAge) * time.Second
+		if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+			ttl -= time.Duration(age) * time.Second
+		}
+		return max(ttl, 0), true, true
+	}
+
+	if value := header.Get("Expires"); value != "" {
+		expires, err := http.ParseTime(value)
+		if err != nil {
+			// An invalid Expires means the response is already stale.
+			return 0, true, true
+		}
+
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		return max(expires.Sub(date), 0), true, true
+	}
+
+	return 0, true, false
+}
+
+// hostTTL returns the override for the host of rawURL, using the most specific
+// matching pattern.
+func hostTTL(overrides map[string]time.Duration, rawURL string) (time.Duration, bool) {
+	matches := matchingHostPatterns(slices.Collect(maps.Keys(overrides)), rawURL)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	return overrides[matches[len(matches)-1]], true
+}
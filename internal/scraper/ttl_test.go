@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func TestHeaderTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		header    map[string]string
+		ttl       time.Duration
+		cacheable bool
+		explicit  bool
+	}{
+		{"no headers", nil, 0, true, false},
+		{"max-age", map[string]string{"Cache-Control": "public, max-age=600"}, 10 * time.Minute, true, true},
+		{"max-age minus age", map[string]string{"Cache-Control": "max-age=600", "Age": "100"}, 500 * time.Second, true, true},
+		{"no-store", map[string]string{"Cache-Control": "no-store, max-age=600"}, 0, false, true},
+		{"no-cache", map[string]string{"Cache-Control": "no-cache"}, 0, true, true},
+		{"max-age wins over expires", map[string]string{"Cache-Control": "max-age=60", "Expires": "Wed, 01 Jan 2025 14:00:00 GMT"}, time.Minute, true, true},
+		{"expires relative to date", map[string]string{"Date": "Wed, 01 Jan 2025 11:00:00 GMT", "Expires": "Wed, 01 Jan 2025 13:00:00 GMT"}, 2 * time.Hour, true, true},
+		{"expires without date", map[string]string{"Expires": "Wed, 01 Jan 2025 12:30:00 GMT"}, 30 * time.Minute, true, true},
+		{"invalid expires", map[string]string{"Expires": "0"}, 0, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			for name, value := range tt.header {
+				header.Set(name, value)
+			}
+
+			ttl, cacheable, explicit := headerTTL(header, now)
+			if ttl != tt.ttl || cacheable != tt.cacheable || explicit != tt.explicit {
+				t.Errorf("Expected (%v, %v, %v), but got (%v, %v, %v)", tt.ttl, tt.cacheable, tt.explicit, ttl, cacheable, explicit)
+			}
+		})
+	}
+}
+
+func TestEntryTTL(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Database.TTLMode = TTLModeHTTP
+	cfg.Database.MinTTL = time.Minute
+	cfg.Database.MaxTTL = time.Hour
+	cfg.Database.HostTTL = map[string]time.Duration{
+		"*.example.com":   2 * time.Hour,
+		"api.example.com": 5 * time.Minute,
+		"*.com":           3 * time.Hour,
+	}
+	s := newTestScraper(t, cfg, newMapCache())
+
+	record := func(url, cacheControl string) cacheRecord {
+		header := make(http.Header)
+		if cacheControl != "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+		return cacheRecord{Data: ScrapedData{URL: url}, Header: header}
+	}
+
+	tests := []struct {
+		name      string
+		record    cacheRecord
+		ttl       time.Duration
+		cacheable bool
+	}{
+		{"clamped to min_ttl", record("https://example.org/", "max-age=5"), time.Minute, true},
+		{"clamped to max_ttl", record("https://example.org/", "max-age=86400"), time.Hour, true},
+		{"fallback to expiration", record("https://example.org/", ""), cfg.Database.Expiration, true},
+		{"exact host override", record("https://api.example.com/v1", "max-age=5"), 5 * time.Minute, true},
+		{"most specific glob override", record("https://www.example.com/", "max-age=5"), 2 * time.Hour, true},
+		{"no-store beats override", record("https://www.example.com/", "no-store"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, cacheable := s.entryTTL(tt.record, time.Now())
+			if ttl != tt.ttl || cacheable != tt.cacheable {
+				t.Errorf("Expected (%v, %v), but got (%v, %v)", tt.ttl, tt.cacheable, ttl, cacheable)
+			}
+		})
+	}
+
+	t.Run("fixed mode ignores headers", func(t *testing.T) {
+		cfg := config.Defaults()
+		s := newTestScraper(t, cfg, newMapCache())
+		ttl, cacheable := s.entryTTL(record("https://example.org/", "no-store"), time.Now())
+		if ttl != cfg.Database.Expiration || !cacheable {
+			t.Errorf("Expected (%v, true), but got (%v, %v)", cfg.Database.Expiration, ttl, cacheable)
+		}
+	})
+}
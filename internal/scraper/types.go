@@ -1,9 +1,24 @@
 package scraper
 
 import (
+	"net/http"
 	"time"
 )
 
+// Request describes a single HTTP request issued by the scraper.
+type Request struct {
+	URL    string
+	Method string
+	Header http.Header
+	Body   []byte
+
+	// Hashes of the credentials and session cookies added when the request is sent, empty without any
+	Identity string
+
+	// Set when the scraper made the request conditional to revalidate its cached response
+	Revalidating bool
+}
+
 type ScrapedData struct {
 	URL          string        `json:"url"`
 	Status       int           `json:"status"`
@@ -13,6 +28,15 @@ type ScrapedData struct {
 	ResponseTime time.Duration `json:"response_time"`
 	Timestamp    time.Time     `json:"timestamp"`
 
+	// Why the URL was not fetched, e.g. because robots.txt disallows it
+	Skipped string `json:"skipped,omitempty"`
+
+	// Set when an expired cache entry was served because the URL could not be scraped
+	Stale bool `json:"stale,omitempty"`
+
+	// Response headers, only populated when requested
+	Headers http.Header `json:"headers,omitempty"`
+
 	// CSS selector results
 	Extracted map[string][]string `json:"extracted,omitempty"`
 
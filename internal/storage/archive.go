@@ -0,0 +1,267 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Archive formats accepted by NewArchiveWriter and NewArchiveReader
+const (
+	ArchiveNDJSON = "ndjson" // one JSON object per line, bodies inline
+	ArchiveTar    = "tar"    // a directory per entry holding entry.json and the raw body
+)
+
+// Files stored in every entry directory of a tar archive
+const (
+	archiveEntryFile = "entry.json"
+	archiveBodyFile  = "body"
+)
+
+// ErrArchiveFormat is returned for archive formats other than ndjson and tar.
+var ErrArchiveFormat = errors.New("unknown archive format")
+
+// ArchiveEntry is a cache entry in a portable archive. The stored value is
+// archived decoded, as readable metadata and the raw response body, so archives
+// can be reviewed and outlive changes to the cache's internal encoding.
+type ArchiveEntry struct {
+	Key       string          `json:"key"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"` // JSON description of the value, e.g. URL, status and headers
+	Body      []byte          `json:"-"`                  // raw response body, stored next to the metadata
+}
+
+// NewArchiveEntry converts a stored entry, decoded into metadata and body, to its archived form.
+func NewArchiveEntry(key string, entry CacheEntry, metadata json.RawMessage, body []byte) ArchiveEntry {
+	return ArchiveEntry{
+		Key:       key,
+		CreatedAt: entry.CreatedAt,
+		ExpiresAt: entry.ExpirationTime,
+		Metadata:  metadata,
+		Body:      body,
+	}
+}
+
+// CacheEntry converts an archived entry back to the stored form, holding value
+// re-encoded from its metadata and body.
+func (a ArchiveEntry) CacheEntry(value []byte) CacheEntry {
+	return CacheEntry{
+		Value:          value,
+		ExpirationTime: a.ExpiresAt,
+		CreatedAt:      a.CreatedAt,
+	}
+}
+
+// ArchiveWriter writes cache entries to an archive. Close must be called to
+// complete the archive; it does not close the underlying writer.
+type ArchiveWriter interface {
+	Write(entry ArchiveEntry) error
+	Close() error
+}
+
+// ArchiveReader reads cache entries from an archive. Next returns io.EOF once
+// every entry has been read.
+type ArchiveReader interface {
+	Next() (ArchiveEntry, error)
+}
+
+// ArchiveFormat guesses the archive format from a file name, defaulting to ndjson.
+func ArchiveFormat(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), ".tar") {
+		return ArchiveTar
+	}
+	return ArchiveNDJSON
+}
+
+// NewArchiveWriter returns a writer for the given archive format.
+func NewArchiveWriter(w io.Writer, format string) (ArchiveWriter, error) {
+	switch strings.ToLower(format) {
+	case ArchiveNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w)}, nil
+	case ArchiveTar:
+		return &tarWriter{tw: tar.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected %s or %s", ErrArchiveFormat, format, ArchiveNDJSON, ArchiveTar)
+	}
+}
+
+// NewArchiveReader returns a reader for the given archive format.
+func NewArchiveReader(r io.Reader, format string) (ArchiveReader, error) {
+	switch strings.ToLower(format) {
+	case ArchiveNDJSON:
+		return &ndjsonReader{dec: json.NewDecoder(r)}, nil
+	case ArchiveTar:
+		return &tarReader{tr: tar.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected %s or %s", ErrArchiveFormat, format, ArchiveNDJSON, ArchiveTar)
+	}
+}
+
+// ndjsonEntry is the line written for an entry. Bodies are written as text
+// unless they are not valid UTF-8.
+type ndjsonEntry struct {
+	ArchiveEntry
+	Body       *string `json:"body,omitempty"`
+	BodyBase64 []byte  `json:"body_base64,omitempty"`
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+func (n *ndjsonWriter) Write(entry ArchiveEntry) error {
+	line := ndjsonEntry{ArchiveEntry: entry}
+	switch {
+	case len(entry.Body) == 0:
+	case utf8.Valid(entry.Body):
+		body := string(entry.Body)
+		line.Body = &body
+	default:
+		line.BodyBase64 = entry.Body
+	}
+
+	if err := newArchiveEncoder(n.w).Encode(line); err != nil {
+		return fmt.Errorf("failed to write entry %s: %w", entry.Key, err)
+	}
+	return nil
+}
+
+// newArchiveEncoder returns a JSON encoder that leaves markup in bodies and
+// metadata unescaped, so archives stay readable.
+func newArchiveEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
+
+type ndjsonReader struct {
+	dec *json.Decoder
+}
+
+func (n *ndjsonReader) Next() (ArchiveEntry, error) {
+	var line ndjsonEntry
+	if err := n.dec.Decode(&line); err != nil {
+		if err == io.EOF {
+			return ArchiveEntry{}, io.EOF
+		}
+		return ArchiveEntry{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if line.Key == "" {
+		return ArchiveEntry{}, errors.New("failed to read archive: entry without key")
+	}
+
+	entry := line.ArchiveEntry
+	entry.Body = line.BodyBase64
+	if line.Body != nil {
+		entry.Body = []byte(*line.Body)
+	}
+	return entry, nil
+}
+
+type tarWriter struct {
+	tw *tar.Writer
+}
+
+func (t *tarWriter) Write(entry ArchiveEntry) error {
+	var header bytes.Buffer
+	enc := newArchiveEncoder(&header)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entry); err != nil {
+		return fmt.Errorf("failed to encode entry %s: %w", entry.Key, err)
+	}
+
+	sum := sha256.Sum256([]byte(entry.Key))
+	dir := hex.EncodeToString(sum[:])
+	modTime := entry.CreatedAt
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{archiveEntryFile, header.Bytes()},
+		{archiveBodyFile, entry.Body},
+	}
+	for _, file := range files {
+		err := t.tw.WriteHeader(&tar.Header{
+			Name:    path.Join(dir, file.name),
+			Mode:    0o644,
+			Size:    int64(len(file.data)),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write entry %s: %w", entry.Key, err)
+		}
+		if _, err := t.tw.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write entry %s: %w", entry.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *tarWriter) Close() error {
+	return t.tw.Close()
+}
+
+// tarReader expects the layout written by tarWriter: entry.json directly
+// followed by the body of the same entry. Other files are ignored.
+type tarReader struct {
+	tr *tar.Reader
+}
+
+func (t *tarReader) Next() (ArchiveEntry, error) {
+	for {
+		header, err := t.tr.Next()
+		if err == io.EOF {
+			return ArchiveEntry{}, io.EOF
+		}
+		if err != nil {
+			return ArchiveEntry{}, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || path.Base(header.Name) != archiveEntryFile {
+			continue
+		}
+
+		var entry ArchiveEntry
+		if err := json.NewDecoder(t.tr).Decode(&entry); err != nil {
+			return ArchiveEntry{}, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		if entry.Key == "" {
+			return ArchiveEntry{}, fmt.Errorf("failed to read %s: entry without key", header.Name)
+		}
+
+		bodyName := path.Join(path.Dir(header.Name), archiveBodyFile)
+		header, err = t.tr.Next()
+		if err != nil || header.Name != bodyName {
+			return ArchiveEntry{}, fmt.Errorf("failed to read archive: missing %s", bodyName)
+		}
+
+		entry.Body, err = io.ReadAll(t.tr)
+		if err != nil {
+			return ArchiveEntry{}, fmt.Errorf("failed to read %s: %w", bodyName, err)
+		}
+
+		return entry, nil
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	now := time.Now().Round(0).UTC()
+	entries := []ArchiveEntry{
+		{Key: "response/abc", CreatedAt: now, ExpiresAt: now.Add(1 * time.Hour), Metadata: json.RawMessage(`{"url":"https://example.com/"}`), Body: []byte("<h1>Hello</h1>\n")},
+		{Key: "response/def", CreatedAt: now, ExpiresAt: now.Add(-1 * time.Hour), Metadata: json.RawMessage(`{"url":"https://example.com/logo.png"}`), Body: []byte{0x00, 0xff, '\n'}},
+		{Key: "result/abc/def", CreatedAt: now, ExpiresAt: now.Add(1 * time.Hour), Metadata: json.RawMessage(`{"url":"https://example.com/","status":200}`)},
+	}
+
+	for _, format := range []string{ArchiveNDJSON, ArchiveTar} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewArchiveWriter(&buf, format)
+			if err != nil {
+				t.Fatalf("Failed to create archive writer: %v", err)
+			}
+			for _, entry := range entries {
+				if err := w.Write(entry); err != nil {
+					t.Fatalf("Failed to write entry: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Failed to close archive: %v", err)
+			}
+
+			r, err := NewArchiveReader(&buf, format)
+			if err != nil {
+				t.Fatalf("Failed to create archive reader: %v", err)
+			}
+			for _, want := range entries {
+				got, err := r.Next()
+				if err != nil {
+					t.Fatalf("Failed to read entry: %v", err)
+				}
+				if got.Key != want.Key || !bytes.Equal(got.Body, want.Body) {
+					t.Errorf("Expected entry %s with body %q, but got %s with %q", want.Key, want.Body, got.Key, got.Body)
+				}
+				if !got.ExpiresAt.Equal(want.ExpiresAt) || !got.CreatedAt.Equal(want.CreatedAt) {
+					t.Errorf("Expected times of %s to survive the round trip", want.Key)
+				}
+				var metadata bytes.Buffer
+				if err := json.Compact(&metadata, got.Metadata); err != nil || metadata.String() != string(want.Metadata) {
+					t.Errorf("Expected metadata %s, but got %s", want.Metadata, got.Metadata)
+				}
+			}
+			if _, err := r.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("Expected io.EOF after the last entry, but got %v", err)
+			}
+		})
+	}
+
+	t.Run("Test NDJSON bodies are readable", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, _ := NewArchiveWriter(&buf, ArchiveNDJSON)
+		_ = w.Write(entries[0])
+		_ = w.Close()
+
+		if !strings.Contains(buf.String(), `"body":"<h1>Hello</h1>\n"`) {
+			t.Errorf("Expected the body to be written as text, but got %s", buf.String())
+		}
+	})
+
+	if _, err := NewArchiveWriter(io.Discard, "zip"); !errors.Is(err, ErrArchiveFormat) {
+		t.Errorf("Expected error %v, but got %v", ErrArchiveFormat, err)
+	}
+}
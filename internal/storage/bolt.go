@@ -1,9 +1,8 @@
 package storage
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
@@ -11,6 +10,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/JesterSe7en/porygo/config"
 	"go.etcd.io/bbolt"
 	bboltErrors "go.etcd.io/bbolt/errors"
 )
@@ -19,12 +19,18 @@ const (
 	// Default cache file permissions
 	cacheFileMode = 0o600
 
+	// Name of the database file inside the cache directory
+	cacheFileName = "cache.db"
+
 	// Default bucket name for cache entries
 	defaultBucketName = "cache"
-)
 
-// Default bucket name as byte slice since bbolt requires byte slices for bucket names
-var bucketName = []byte(defaultBucketName)
+	// Maximum size of a single transaction while compacting
+	compactTxMaxSize = 64 * 1024 * 1024
+
+	// How long to wait for another process to release the database lock
+	defaultLockTimeout = 1 * time.Second
+)
 
 // Common errors
 var (
@@ -32,19 +38,50 @@ var (
 	ErrBucketNotFound = errors.New("bucket not found")
 	ErrEncoding       = errors.New("failed to encode cache entry")
 	ErrDecoding       = errors.New("failed to decode cache entry")
+	ErrCorrupt        = errors.New("cache entry is corrupt")
+	ErrReadOnly       = errors.New("cache is opened read-only")
 )
 
 type boltCache struct {
-	db *bbolt.DB
+	db       *bbolt.DB
+	cfg      config.Database
+	buckets  bucketNames
+	accesses accessLog // reads not yet written to the metadata
+}
+
+// bucketNames are the buckets backing a single cache namespace.
+type bucketNames struct {
+	cache []byte // encoded entries
+	meta  []byte // entryMeta per key
+	stats []byte // namespace-wide counters
+}
+
+// namespaceBuckets returns the buckets of namespace. The default namespace keeps
+// the original bucket names so existing databases stay readable.
+func namespaceBuckets(namespace string) bucketNames {
+	suffix := ""
+	if namespace != "" {
+		suffix = ":" + namespace
+	}
+
+	return bucketNames{
+		cache: []byte(defaultBucketName + suffix),
+		meta:  []byte("meta" + suffix),
+		stats: []byte("stats" + suffix),
+	}
 }
 
-// getCachePath determines the appropriate cache directory path for the current platform.
-// It follows XDG Base Directory specification on Unix-like systems and uses appropriate
-// directories on Windows.
-func getCachePath() (string, error) {
+// getCacheDir determines the cache directory. database.path takes precedence;
+// otherwise it follows XDG Base Directory specification on Unix-like systems and
+// uses appropriate directories on Windows and macOS.
+func getCacheDir(cfg config.Database) (string, error) {
+	if cfg.Path != "" {
+		return cfg.Path, nil
+	}
+
 	// Check for XDG_CACHE_HOME environment variable first
 	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
-		return filepath.Join(xdgCache, "porygo", "cache.db"), nil
+		return filepath.Join(xdgCache, "porygo"), nil
 	}
 
 	home, err := os.UserHomeDir()
@@ -54,47 +91,81 @@ func getCachePath() (string, error) {
 
 	switch runtime.GOOS {
 	case "windows":
-		return filepath.Join(home, "AppData", "Local", "porygo", "cache.db"), nil
+		return filepath.Join(home, "AppData", "Local", "porygo"), nil
 	case "darwin":
-		return filepath.Join(home, "Library", "Caches", "porygo", "cache.db"), nil
+		return filepath.Join(home, "Library", "Caches", "porygo"), nil
 	default: // Unix-like systems
-		return filepath.Join(home, ".cache", "porygo", "cache.db"), nil
+		return filepath.Join(home, ".cache", "porygo"), nil
 	}
 }
 
-func newBoltCacheAt(pathDB string) (CacheStorage, error) {
-	// Ensure the directory exists before opening the database.
-	if err := os.MkdirAll(filepath.Dir(pathDB), 0o750); err != nil {
+func newBoltCacheAt(pathDB string, cfg config.Database) (CacheStorage, error) {
+	if cfg.ReadOnly {
+		// A read-only database cannot be created, so it has to exist already.
+		if _, err := os.Stat(pathDB); err != nil {
+			return nil, fmt.Errorf("cannot open cache read-only: %w", err)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(pathDB), 0o750); err != nil {
+		// Ensure the directory exists before opening the database.
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
+	buckets := namespaceBuckets(cfg.Namespace)
+	db, err := openBoltDB(pathDB, cfg, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltCache{db: db, cfg: cfg, buckets: buckets}, nil
+}
+
+// openBoltDB opens the database at pathDB and, unless it is read-only, makes sure
+// the buckets of the namespace exist. Read-only databases take a shared lock so
+// several processes can read the same cache at once.
+func openBoltDB(pathDB string, cfg config.Database, buckets bucketNames) (*bbolt.DB, error) {
+	timeout := cfg.LockTimeout
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+
 	db, err := bbolt.Open(pathDB, cacheFileMode, &bbolt.Options{
-		Timeout: 1 * time.Second,
+		Timeout:  timeout,
+		ReadOnly: cfg.ReadOnly,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open BoltDB at %s: %w", pathDB, err)
 	}
 
+	if cfg.ReadOnly {
+		return db, nil
+	}
+
 	if err := db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucketName)
-		if err != nil {
-			return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
+		for _, name := range [][]byte{buckets.cache, buckets.meta, buckets.stats} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", name, err)
+			}
 		}
-		return nil
+		return backfillMeta(tx, buckets)
 	}); err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	return &boltCache{db: db}, nil
+	return db, nil
+}
+
+func init() {
+	Register(BackendBolt, NewBoltCache)
 }
 
-func NewBoltCache() (CacheStorage, error) {
-	pathDB, err := getCachePath()
+// NewBoltCache opens the BoltDB cache inside the configured cache directory.
+func NewBoltCache(cfg config.Database) (CacheStorage, error) {
+	dir, err := getCacheDir(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get cache location: %w", err)
 	}
-	return newBoltCacheAt(pathDB)
+	return newBoltCacheAt(filepath.Join(dir, cacheFileName), cfg)
 }
 
 // Get retrieves a cache entry by key.
@@ -107,12 +178,18 @@ func (b *boltCache) Get(ctx context.Context, key string) (CacheEntry, error) {
 	var value []byte
 
 	err := b.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(bucketName)
+		bucket := tx.Bucket(b.buckets.cache)
 		if bucket == nil {
-			return ErrBucketNotFound
+			// Only possible for a namespace never written to in a read-only database.
+			return nil
 		}
 
-		value = bucket.Get([]byte(key))
+		stored := bucket.Get([]byte(key))
+		if stored == nil {
+			return nil
+		}
+		// Copy since the slice is only valid for the lifetime of the transaction.
+		value = append([]byte(nil), stored...)
 		return nil
 	})
 	if err != nil {
@@ -123,6 +200,13 @@ func (b *boltCache) Get(ctx context.Context, key string) (CacheEntry, error) {
 		return CacheEntry{}, ErrNotFound
 	}
 
+	// Access times only matter when entries may be evicted.
+	if limited(b.cfg) && !b.cfg.ReadOnly && b.accesses.record(key) {
+		if err := b.flushAccesses(); err != nil {
+			return CacheEntry{}, fmt.Errorf("failed to record cache accesses: %w", err)
+		}
+	}
+
 	entry, err := decodeEntry(value)
 	if err != nil {
 		return CacheEntry{}, fmt.Errorf("failed to decode entry: %w", err)
@@ -137,13 +221,17 @@ func (b *boltCache) Set(ctx context.Context, key string, entry CacheEntry) error
 		return errors.New("key cannot be empty")
 	}
 
+	if b.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+
 	encodedEntry, err := encodeEntry(entry)
 	if err != nil {
 		return fmt.Errorf("failed to encode entry: %w", err)
 	}
 
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(bucketName)
+		bucket := tx.Bucket(b.buckets.cache)
 		if bucket == nil {
 			return ErrBucketNotFound
 		}
@@ -152,7 +240,31 @@ func (b *boltCache) Set(ctx context.Context, key string, entry CacheEntry) error
 			return fmt.Errorf("failed to put key-value pair: %w", err)
 		}
 
+		if err := putMeta(tx, b.buckets, []byte(key), len(encodedEntry)); err != nil {
+			return fmt.Errorf("failed to put key metadata: %w", err)
+		}
+
+		if limited(b.cfg) {
+			// Eviction has to see the reads made since the last write.
+			if err := touchMeta(tx, b.buckets, b.accesses.take()); err != nil {
+				return fmt.Errorf("failed to record cache accesses: %w", err)
+			}
+			return evict(tx, b.buckets, b.cfg, []byte(key))
+		}
+
+		return nil
+	})
+}
+
+// flushAccesses writes the buffered reads, batched with those of concurrent callers.
+func (b *boltCache) flushAccesses() error {
+	accesses := b.accesses.take()
+	if len(accesses) == 0 {
 		return nil
+	}
+
+	return b.db.Batch(func(tx *bbolt.Tx) error {
+		return touchMeta(tx, b.buckets, accesses)
 	})
 }
 
@@ -162,8 +274,12 @@ func (b *boltCache) Delete(ctx context.Context, key string) error {
 		return errors.New("key cannot be empty")
 	}
 
+	if b.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(bucketName)
+		bucket := tx.Bucket(b.buckets.cache)
 		if bucket == nil {
 			return ErrBucketNotFound
 		}
@@ -172,60 +288,264 @@ func (b *boltCache) Delete(ctx context.Context, key string) error {
 			return fmt.Errorf("failed to delete key: %w", err)
 		}
 
+		if err := deleteMeta(tx, b.buckets, []byte(key)); err != nil {
+			return fmt.Errorf("failed to delete key metadata: %w", err)
+		}
+
 		return nil
 	})
 }
 
-// ClearCache removes all entries from the cache by recreating the bucket.
-func (b *boltCache) Clear(ctx context.Context) error {
-	return b.db.Update(func(tx *bbolt.Tx) error {
-		// Delete existing bucket
-		if err := tx.DeleteBucket(bucketName); err != nil {
-			// Ignore error if bucket doesn't exist
-			if !errors.Is(err, bboltErrors.ErrBucketNotFound) {
-				return fmt.Errorf("failed to delete bucket: %w", err)
+// ForEach calls fn for every entry in the cache in key order.
+func (b *boltCache) ForEach(ctx context.Context, fn func(key string, entry CacheEntry) error) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(b.buckets.cache)
+		if bucket == nil {
+			// Only possible for a namespace never written to in a read-only database.
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			entry, err := decodeEntry(v)
+			if errors.Is(err, ErrCorrupt) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode entry %s: %w", k, err)
 			}
+
+			return fn(string(k), entry)
+		})
+	})
+}
+
+// DeleteFunc removes every entry for which match returns true and reports how many were removed.
+func (b *boltCache) DeleteFunc(ctx context.Context, match func(key string, entry CacheEntry) bool) (int, error) {
+	if b.cfg.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	deleted := 0
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(b.buckets.cache)
+		if bucket == nil {
+			return ErrBucketNotFound
 		}
 
-		// Recreate bucket
-		if _, err := tx.CreateBucket(bucketName); err != nil {
-			return fmt.Errorf("failed to recreate bucket: %w", err)
+		// Collect first; deleting while a cursor walks the bucket can skip keys.
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			entry, err := decodeEntry(v)
+			if errors.Is(err, ErrCorrupt) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode entry %s: %w", k, err)
+			}
+
+			if match(string(k), entry) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return fmt.Errorf("failed to delete key: %w", err)
+			}
+			if err := deleteMeta(tx, b.buckets, key); err != nil {
+				return fmt.Errorf("failed to delete key metadata: %w", err)
+			}
+		}
+
+		deleted = len(keys)
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
 }
 
-// Close closes the database connection.
-func (b *boltCache) Close() error {
-	if b.db != nil {
-		err := b.db.Close()
-		b.db = nil
+// Compact rewrites the database into a fresh file so pages freed by deleted
+// entries are returned to the filesystem. It must not run concurrently with
+// other cache operations.
+func (b *boltCache) Compact(ctx context.Context) error {
+	if b.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+
+	pathDB := b.db.Path()
+	tmpPath := pathDB + ".compact"
+
+	dst, err := bbolt.Open(tmpPath, cacheFileMode, &bbolt.Options{
+		Timeout: 1 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open compaction target: %w", err)
+	}
+
+	if err := bbolt.Compact(dst, b.db, compactTxMaxSize); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close compacted database: %w", err)
+	}
+
+	if err := b.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, pathDB); err != nil {
+		os.Remove(tmpPath)
+		// Reopen the original file so the cache stays usable.
+		if db, openErr := openBoltDB(pathDB, b.cfg, b.buckets); openErr == nil {
+			b.db = db
+		}
+		return fmt.Errorf("failed to replace database with compacted copy: %w", err)
+	}
+
+	db, err := openBoltDB(pathDB, b.cfg, b.buckets)
+	if err != nil {
 		return err
 	}
+
+	b.db = db
 	return nil
 }
 
-// encodeEntry serializes a CacheEntry using gob encoding.
-func encodeEntry(entry CacheEntry) ([]byte, error) {
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-
-	if err := encoder.Encode(entry); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+// ClearCache removes all entries of the namespace by recreating its buckets.
+func (b *boltCache) Clear(ctx context.Context) error {
+	if b.cfg.ReadOnly {
+		return ErrReadOnly
 	}
 
-	return buf.Bytes(), nil
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{b.buckets.cache, b.buckets.meta} {
+			// Delete existing bucket
+			if err := tx.DeleteBucket(name); err != nil {
+				// Ignore error if bucket doesn't exist
+				if !errors.Is(err, bboltErrors.ErrBucketNotFound) {
+					return fmt.Errorf("failed to delete bucket: %w", err)
+				}
+			}
+
+			// Recreate bucket
+			if _, err := tx.CreateBucket(name); err != nil {
+				return fmt.Errorf("failed to recreate bucket: %w", err)
+			}
+		}
+
+		return putUsage(tx, b.buckets, usage{})
+	})
+}
+
+// Evictions returns how many entries have been evicted to stay within the size limits.
+func (b *boltCache) Evictions() (uint64, error) {
+	var evictions uint64
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		stats := tx.Bucket(b.buckets.stats)
+		if stats == nil {
+			return nil
+		}
+		if v := stats.Get(evictionsKey); len(v) == 8 {
+			evictions = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+
+	return evictions, err
 }
 
-// decodeEntry deserializes a CacheEntry from gob-encoded data.
-func decodeEntry(data []byte) (CacheEntry, error) {
-	var entry CacheEntry
-	decoder := gob.NewDecoder(bytes.NewReader(data))
+// FileSize returns the size of the database file in bytes.
+func (b *boltCache) FileSize() (int64, error) {
+	info, err := os.Stat(b.db.Path())
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat database file: %w", err)
+	}
+	return info.Size(), nil
+}
 
-	if err := decoder.Decode(&entry); err != nil {
-		return CacheEntry{}, fmt.Errorf("%w: %w", ErrDecoding, err)
+// Verify checks the database structure and decodes every entry of the namespace.
+func (b *boltCache) Verify(ctx context.Context, repair bool) (VerifyReport, error) {
+	if repair && b.cfg.ReadOnly {
+		return VerifyReport{}, ErrReadOnly
 	}
 
-	return entry, nil
+	report := VerifyReport{Corrupt: []CorruptEntry{}}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		for err := range tx.Check() {
+			report.Problems = append(report.Problems, err.Error())
+		}
+
+		bucket := tx.Bucket(b.buckets.cache)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			report.Checked++
+			if _, err := decodeEntry(v); err != nil {
+				report.Corrupt = append(report.Corrupt, CorruptEntry{Key: string(k), Reason: err.Error()})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to verify database: %w", err)
+	}
+
+	if !repair || len(report.Corrupt) == 0 {
+		return report, nil
+	}
+
+	err = b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(b.buckets.cache)
+		for _, corrupt := range report.Corrupt {
+			if err := bucket.Delete([]byte(corrupt.Key)); err != nil {
+				return fmt.Errorf("failed to delete key: %w", err)
+			}
+			if err := deleteMeta(tx, b.buckets, []byte(corrupt.Key)); err != nil {
+				return fmt.Errorf("failed to delete key metadata: %w", err)
+			}
+		}
+		report.Removed = len(report.Corrupt)
+		return nil
+	})
+
+	return report, err
+}
+
+// Close writes any buffered reads and closes the database connection.
+func (b *boltCache) Close() error {
+	if b.db != nil {
+		flushErr := b.flushAccesses()
+		err := b.db.Close()
+		b.db = nil
+		return errors.Join(flushErr, err)
+	}
+	return nil
 }
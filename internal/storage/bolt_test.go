@@ -3,14 +3,19 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"path"
 	"testing"
 	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"go.etcd.io/bbolt"
 )
 
 func Test_boltCache(t *testing.T) {
 	t.Run("Test NewBoltCache", func(t *testing.T) {
-		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"))
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
 		if err != nil {
 			t.Fatalf("Failed to create new bolt cache: %v", err)
 		}
@@ -22,7 +27,7 @@ func Test_boltCache(t *testing.T) {
 	})
 
 	t.Run("Test Get and Set", func(t *testing.T) {
-		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"))
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
 
 		if err != nil {
 			t.Fatalf("Failed to create new bolt cache: %v", err)
@@ -50,7 +55,7 @@ func Test_boltCache(t *testing.T) {
 	})
 
 	t.Run("Test Delete", func(t *testing.T) {
-		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"))
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
 		if err != nil {
 			t.Fatalf("Failed to create new bolt cache: %v", err)
 		}
@@ -77,7 +82,7 @@ func Test_boltCache(t *testing.T) {
 	})
 
 	t.Run("Test Clear", func(t *testing.T) {
-		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"))
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
 		if err != nil {
 			t.Fatalf("Failed to create new bolt cache: %v", err)
 		}
@@ -149,3 +154,459 @@ func Test_boltCache(t *testing.T) {
 
 	})
 }
+
+func Test_boltCacheForEachAndStats(t *testing.T) {
+	cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to create new bolt cache: %v", err)
+	}
+	defer cache.Close()
+
+	entries := map[string]CacheEntry{
+		"test-key-1": {Value: []byte("test-value-1"), ExpirationTime: time.Now().Add(1 * time.Hour)},
+		"test-key-2": {Value: []byte("test-value-2"), ExpirationTime: time.Now().Add(-1 * time.Hour)},
+	}
+	for key, entry := range entries {
+		if err := cache.Set(context.Background(), key, entry); err != nil {
+			t.Fatalf("Failed to set cache entry: %v", err)
+		}
+	}
+
+	t.Run("Test ForEach", func(t *testing.T) {
+		seen := map[string]string{}
+		err := cache.ForEach(context.Background(), func(key string, entry CacheEntry) error {
+			seen[key] = string(entry.Value)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to iterate cache: %v", err)
+		}
+
+		if len(seen) != len(entries) {
+			t.Fatalf("Expected %d entries, but got %d", len(entries), len(seen))
+		}
+		for key, entry := range entries {
+			if seen[key] != string(entry.Value) {
+				t.Errorf("Expected value %s for %s, but got %s", entry.Value, key, seen[key])
+			}
+		}
+	})
+
+	t.Run("Test CollectStats", func(t *testing.T) {
+		stats, err := CollectStats(context.Background(), cache)
+		if err != nil {
+			t.Fatalf("Failed to collect stats: %v", err)
+		}
+
+		if stats.Entries != 2 {
+			t.Errorf("Expected 2 entries, but got %d", stats.Entries)
+		}
+		if stats.Expired != 1 {
+			t.Errorf("Expected 1 expired entry, but got %d", stats.Expired)
+		}
+		if stats.TotalBytes != int64(len("test-value-1")+len("test-value-2")) {
+			t.Errorf("Expected total bytes %d, but got %d", len("test-value-1")+len("test-value-2"), stats.TotalBytes)
+		}
+		if stats.FileSize <= 0 {
+			t.Errorf("Expected a positive file size, but got %d", stats.FileSize)
+		}
+	})
+}
+
+func Test_boltCachePrune(t *testing.T) {
+	cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to create new bolt cache: %v", err)
+	}
+	defer cache.Close()
+
+	if err := cache.Set(context.Background(), "fresh", CacheEntry{
+		Value:          []byte("test-value-1"),
+		ExpirationTime: time.Now().Add(1 * time.Hour),
+	}); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	for i := range 100 {
+		if err := cache.Set(context.Background(), fmt.Sprintf("stale-%d", i), CacheEntry{
+			Value:          bytes.Repeat([]byte("x"), 4096),
+			ExpirationTime: time.Now().Add(-1 * time.Hour),
+		}); err != nil {
+			t.Fatalf("Failed to set cache entry: %v", err)
+		}
+	}
+
+	t.Run("Test DeleteFunc expired", func(t *testing.T) {
+		now := time.Now()
+		pruned, err := cache.DeleteFunc(context.Background(), func(key string, entry CacheEntry) bool {
+			return now.After(entry.ExpirationTime)
+		})
+		if err != nil {
+			t.Fatalf("Failed to prune cache: %v", err)
+		}
+		if pruned != 100 {
+			t.Errorf("Expected 100 pruned entries, but got %d", pruned)
+		}
+
+		if _, err := cache.Get(context.Background(), "fresh"); err != nil {
+			t.Errorf("Expected fresh entry to survive pruning, but got %v", err)
+		}
+	})
+
+	t.Run("Test Compact", func(t *testing.T) {
+		compactor, ok := cache.(Compactor)
+		if !ok {
+			t.Fatal("Expected bolt cache to implement Compactor")
+		}
+
+		before, _ := cache.(FileSizer).FileSize()
+		if err := compactor.Compact(context.Background()); err != nil {
+			t.Fatalf("Failed to compact cache: %v", err)
+		}
+		after, _ := cache.(FileSizer).FileSize()
+
+		if after >= before {
+			t.Errorf("Expected compaction to shrink the file from %d bytes, but got %d", before, after)
+		}
+
+		entry, err := cache.Get(context.Background(), "fresh")
+		if err != nil {
+			t.Fatalf("Expected entry to survive compaction, but got %v", err)
+		}
+		if string(entry.Value) != "test-value-1" {
+			t.Errorf("Expected value test-value-1, but got %s", entry.Value)
+		}
+	})
+}
+
+func Test_boltCacheEviction(t *testing.T) {
+	newEntry := func() CacheEntry {
+		return CacheEntry{Value: []byte("test-value"), ExpirationTime: time.Now().Add(1 * time.Hour)}
+	}
+
+	t.Run("Test LRU evicts the least recently used entry", func(t *testing.T) {
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{MaxEntries: 2, Eviction: EvictionLRU})
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		defer cache.Close()
+
+		ctx := context.Background()
+		_ = cache.Set(ctx, "test-key-1", newEntry())
+		_ = cache.Set(ctx, "test-key-2", newEntry())
+		// Touch the older entry so the second one becomes least recently used.
+		if _, err := cache.Get(ctx, "test-key-1"); err != nil {
+			t.Fatalf("Failed to get cache entry: %v", err)
+		}
+		_ = cache.Set(ctx, "test-key-3", newEntry())
+
+		if _, err := cache.Get(ctx, "test-key-2"); err != ErrNotFound {
+			t.Errorf("Expected test-key-2 to be evicted, but got %v", err)
+		}
+		for _, key := range []string{"test-key-1", "test-key-3"} {
+			if _, err := cache.Get(ctx, key); err != nil {
+				t.Errorf("Expected %s to be kept, but got %v", key, err)
+			}
+		}
+
+		evictions, err := cache.(EvictionCounter).Evictions()
+		if err != nil {
+			t.Fatalf("Failed to read evictions: %v", err)
+		}
+		if evictions != 1 {
+			t.Errorf("Expected 1 eviction, but got %d", evictions)
+		}
+	})
+
+	t.Run("Test LFU evicts the least frequently used entry", func(t *testing.T) {
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{MaxEntries: 2, Eviction: EvictionLFU})
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		defer cache.Close()
+
+		ctx := context.Background()
+		_ = cache.Set(ctx, "test-key-1", newEntry())
+		_ = cache.Set(ctx, "test-key-2", newEntry())
+		for range 3 {
+			_, _ = cache.Get(ctx, "test-key-1")
+		}
+		_, _ = cache.Get(ctx, "test-key-2")
+		_ = cache.Set(ctx, "test-key-3", newEntry())
+
+		if _, err := cache.Get(ctx, "test-key-2"); err != ErrNotFound {
+			t.Errorf("Expected test-key-2 to be evicted, but got %v", err)
+		}
+		if _, err := cache.Get(ctx, "test-key-1"); err != nil {
+			t.Errorf("Expected test-key-1 to be kept, but got %v", err)
+		}
+	})
+
+	t.Run("Test max size", func(t *testing.T) {
+		encoded, _ := encodeEntry(newEntry())
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{MaxSize: int64(len(encoded) * 3)})
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		defer cache.Close()
+
+		ctx := context.Background()
+		for i := range 5 {
+			if err := cache.Set(ctx, fmt.Sprintf("test-key-%d", i), newEntry()); err != nil {
+				t.Fatalf("Failed to set cache entry: %v", err)
+			}
+		}
+
+		stats, err := CollectStats(ctx, cache)
+		if err != nil {
+			t.Fatalf("Failed to collect stats: %v", err)
+		}
+		if stats.Entries != 3 {
+			t.Errorf("Expected 3 entries to fit, but got %d", stats.Entries)
+		}
+		if stats.Evictions != 2 {
+			t.Errorf("Expected 2 evictions, but got %d", stats.Evictions)
+		}
+	})
+
+	t.Run("Test deleted entries free their room", func(t *testing.T) {
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{MaxEntries: 2})
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		defer cache.Close()
+
+		ctx := context.Background()
+		_ = cache.Set(ctx, "test-key-1", newEntry())
+		_ = cache.Set(ctx, "test-key-2", newEntry())
+		_ = cache.Set(ctx, "test-key-2", newEntry())
+		if err := cache.Delete(ctx, "test-key-1"); err != nil {
+			t.Fatalf("Failed to delete cache entry: %v", err)
+		}
+		_ = cache.Set(ctx, "test-key-3", newEntry())
+
+		evictions, err := cache.(EvictionCounter).Evictions()
+		if err != nil {
+			t.Fatalf("Failed to read evictions: %v", err)
+		}
+		if evictions != 0 {
+			t.Errorf("Expected no evictions, but got %d", evictions)
+		}
+	})
+
+	t.Run("Test reads are kept across reopening", func(t *testing.T) {
+		pathDB := path.Join(t.TempDir(), "cache.db")
+		cfg := config.Database{MaxEntries: 2, Eviction: EvictionLRU}
+		ctx := context.Background()
+
+		cache, err := newBoltCacheAt(pathDB, cfg)
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		_ = cache.Set(ctx, "test-key-1", newEntry())
+		_ = cache.Set(ctx, "test-key-2", newEntry())
+		if _, err := cache.Get(ctx, "test-key-1"); err != nil {
+			t.Fatalf("Failed to get cache entry: %v", err)
+		}
+		if err := cache.Close(); err != nil {
+			t.Fatalf("Failed to close cache: %v", err)
+		}
+
+		cache, err = newBoltCacheAt(pathDB, cfg)
+		if err != nil {
+			t.Fatalf("Failed to reopen bolt cache: %v", err)
+		}
+		defer cache.Close()
+		_ = cache.Set(ctx, "test-key-3", newEntry())
+
+		if _, err := cache.Get(ctx, "test-key-2"); err != ErrNotFound {
+			t.Errorf("Expected test-key-2 to be evicted, but got %v", err)
+		}
+		if _, err := cache.Get(ctx, "test-key-1"); err != nil {
+			t.Errorf("Expected test-key-1 to be kept, but got %v", err)
+		}
+	})
+}
+
+func Test_boltCacheNamespaces(t *testing.T) {
+	ctx := context.Background()
+	pathDB := path.Join(t.TempDir(), "cache.db")
+	entry := CacheEntry{Value: []byte("test-value"), ExpirationTime: time.Now().Add(1 * time.Hour)}
+
+	cache, err := newBoltCacheAt(pathDB, config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to create new bolt cache: %v", err)
+	}
+	if err := cache.Set(ctx, "test-key", entry); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	cache.Close()
+
+	project, err := newBoltCacheAt(pathDB, config.Database{Namespace: "project"})
+	if err != nil {
+		t.Fatalf("Failed to open namespaced bolt cache: %v", err)
+	}
+	defer project.Close()
+
+	if _, err := project.Get(ctx, "test-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected entry of the default namespace to be hidden, but got %v", err)
+	}
+
+	if err := project.Set(ctx, "test-key", entry); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if err := project.Clear(ctx); err != nil {
+		t.Fatalf("Failed to clear namespace: %v", err)
+	}
+	project.Close()
+
+	cache, err = newBoltCacheAt(pathDB, config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to reopen bolt cache: %v", err)
+	}
+	defer cache.Close()
+
+	if _, err := cache.Get(ctx, "test-key"); err != nil {
+		t.Errorf("Expected clearing a namespace to keep the default namespace, but got %v", err)
+	}
+}
+
+func Test_boltCacheReadOnly(t *testing.T) {
+	ctx := context.Background()
+	pathDB := path.Join(t.TempDir(), "cache.db")
+
+	if _, err := newBoltCacheAt(pathDB, config.Database{ReadOnly: true}); err == nil {
+		t.Fatal("Expected an error opening a missing database read-only")
+	}
+
+	cache, err := newBoltCacheAt(pathDB, config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to create new bolt cache: %v", err)
+	}
+	entry := CacheEntry{Value: []byte("test-value"), ExpirationTime: time.Now().Add(1 * time.Hour)}
+	if err := cache.Set(ctx, "test-key", entry); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	cache.Close()
+
+	readers := make([]CacheStorage, 2)
+	for i := range readers {
+		readers[i], err = newBoltCacheAt(pathDB, config.Database{ReadOnly: true, MaxEntries: 1})
+		if err != nil {
+			t.Fatalf("Failed to open bolt cache read-only: %v", err)
+		}
+		defer readers[i].Close()
+	}
+
+	t.Run("Test reads", func(t *testing.T) {
+		for _, reader := range readers {
+			got, err := reader.Get(ctx, "test-key")
+			if err != nil {
+				t.Fatalf("Failed to get cache entry: %v", err)
+			}
+			if !bytes.Equal(got.Value, entry.Value) {
+				t.Errorf("Expected value %s, but got %s", entry.Value, got.Value)
+			}
+		}
+	})
+
+	t.Run("Test writes are rejected", func(t *testing.T) {
+		reader := readers[0]
+		if err := reader.Set(ctx, "test-key-2", entry); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected error %v from Set, but got %v", ErrReadOnly, err)
+		}
+		if err := reader.Delete(ctx, "test-key"); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected error %v from Delete, but got %v", ErrReadOnly, err)
+		}
+		if err := reader.Clear(ctx); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected error %v from Clear, but got %v", ErrReadOnly, err)
+		}
+		if _, err := reader.DeleteFunc(ctx, func(string, CacheEntry) bool { return true }); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Expected error %v from DeleteFunc, but got %v", ErrReadOnly, err)
+		}
+	})
+
+	t.Run("Test missing namespace is empty", func(t *testing.T) {
+		other, err := newBoltCacheAt(pathDB, config.Database{ReadOnly: true, Namespace: "other"})
+		if err != nil {
+			t.Fatalf("Failed to open bolt cache read-only: %v", err)
+		}
+		defer other.Close()
+
+		if _, err := other.Get(ctx, "test-key"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error %v, but got %v", ErrNotFound, err)
+		}
+		stats, err := CollectStats(ctx, other)
+		if err != nil || stats.Entries != 0 {
+			t.Errorf("Expected no entries, but got %d (%v)", stats.Entries, err)
+		}
+	})
+}
+
+func Test_boltCacheVerify(t *testing.T) {
+	ctx := context.Background()
+	cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
+	if err != nil {
+		t.Fatalf("Failed to create new bolt cache: %v", err)
+	}
+	defer cache.Close()
+
+	entry := CacheEntry{Value: []byte("test-value"), ExpirationTime: time.Now().Add(1 * time.Hour)}
+	for _, key := range []string{"test-key-1", "test-key-2"} {
+		if err := cache.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Failed to set cache entry: %v", err)
+		}
+	}
+
+	b := cache.(*boltCache)
+	err = b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(b.buckets.cache)
+		corrupt := bytes.Clone(bucket.Get([]byte("test-key-2")))
+		corrupt[len(corrupt)-1] ^= 0xff
+		return bucket.Put([]byte("test-key-2"), corrupt)
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt entry: %v", err)
+	}
+
+	t.Run("Test corrupt entries are hidden", func(t *testing.T) {
+		if _, err := cache.Get(ctx, "test-key-2"); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected error %v, but got %v", ErrCorrupt, err)
+		}
+
+		stats, err := CollectStats(ctx, cache)
+		if err != nil {
+			t.Fatalf("Failed to collect stats: %v", err)
+		}
+		if stats.Entries != 1 {
+			t.Errorf("Expected 1 readable entry, but got %d", stats.Entries)
+		}
+	})
+
+	t.Run("Test Verify", func(t *testing.T) {
+		report, err := b.Verify(ctx, false)
+		if err != nil {
+			t.Fatalf("Failed to verify cache: %v", err)
+		}
+		if report.Checked != 2 || len(report.Corrupt) != 1 || report.Corrupt[0].Key != "test-key-2" {
+			t.Fatalf("Expected test-key-2 to be the only corrupt entry of 2, but got %+v", report)
+		}
+		if len(report.Problems) != 0 {
+			t.Errorf("Expected no database problems, but got %v", report.Problems)
+		}
+	})
+
+	t.Run("Test Verify with repair", func(t *testing.T) {
+		report, err := b.Verify(ctx, true)
+		if err != nil {
+			t.Fatalf("Failed to verify cache: %v", err)
+		}
+		if report.Removed != 1 {
+			t.Errorf("Expected 1 removed entry, but got %d", report.Removed)
+		}
+
+		report, err = b.Verify(ctx, false)
+		if err != nil || len(report.Corrupt) != 0 {
+			t.Errorf("Expected no corrupt entries after repair, but got %+v (%v)", report.Corrupt, err)
+		}
+	})
+}
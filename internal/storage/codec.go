@@ -0,0 +1,128 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Stored entries start with a header followed by the gob-encoded CacheEntry,
+// which is gzip-compressed when that saves space:
+//
+//	magic (1) | version (1) | codec (1) | CRC-32 of payload (4) | payload
+//
+// The magic byte can never start a gob stream, so entries written before the
+// header existed are still recognised and decoded as plain gob.
+const (
+	entryMagic         = 0xb0
+	entryFormatVersion = 1
+	entryHeaderSize    = 7
+
+	// Payloads smaller than this are stored uncompressed
+	compressMinSize = 512
+)
+
+// Payload codecs
+const (
+	codecNone byte = iota
+	codecGzip
+)
+
+// encodeEntry serializes a CacheEntry, compressing it when worthwhile and
+// prefixing it with a versioned header and checksum.
+func encodeEntry(entry CacheEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+	}
+
+	payload, codec := buf.Bytes(), codecNone
+	if len(payload) >= compressMinSize {
+		compressed, err := compress(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+		}
+		if len(compressed) < len(payload) {
+			payload, codec = compressed, codecGzip
+		}
+	}
+
+	data := make([]byte, entryHeaderSize, entryHeaderSize+len(payload))
+	data[0] = entryMagic
+	data[1] = entryFormatVersion
+	data[2] = codec
+	binary.BigEndian.PutUint32(data[3:entryHeaderSize], crc32.ChecksumIEEE(payload))
+
+	return append(data, payload...), nil
+}
+
+// decodeEntry deserializes a CacheEntry written by encodeEntry. Entries that
+// fail their checksum or cannot be decompressed or decoded wrap ErrCorrupt.
+func decodeEntry(data []byte) (CacheEntry, error) {
+	if len(data) == 0 || data[0] != entryMagic {
+		return decodeGob(data)
+	}
+
+	if len(data) < entryHeaderSize {
+		return CacheEntry{}, fmt.Errorf("%w: truncated header", ErrCorrupt)
+	}
+	if data[1] != entryFormatVersion {
+		return CacheEntry{}, fmt.Errorf("%w: unsupported format version %d", ErrDecoding, data[1])
+	}
+
+	payload := data[entryHeaderSize:]
+	if sum := binary.BigEndian.Uint32(data[3:entryHeaderSize]); sum != crc32.ChecksumIEEE(payload) {
+		return CacheEntry{}, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	switch data[2] {
+	case codecNone:
+	case codecGzip:
+		decompressed, err := decompress(payload)
+		if err != nil {
+			return CacheEntry{}, fmt.Errorf("%w: %w", ErrCorrupt, err)
+		}
+		payload = decompressed
+	default:
+		return CacheEntry{}, fmt.Errorf("%w: unknown codec %d", ErrCorrupt, data[2])
+	}
+
+	return decodeGob(payload)
+}
+
+// decodeGob decodes a gob-encoded CacheEntry.
+func decodeGob(data []byte) (CacheEntry, error) {
+	var entry CacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		return CacheEntry{}, fmt.Errorf("%w: %w: %w", ErrCorrupt, ErrDecoding, err)
+	}
+	return entry, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEntryCodec(t *testing.T) {
+	entry := CacheEntry{
+		Value:          bytes.Repeat([]byte("<p>compressible</p>"), 200),
+		ExpirationTime: time.Now().Add(1 * time.Hour).Round(0),
+		CreatedAt:      time.Now().Round(0),
+	}
+
+	data, err := encodeEntry(entry)
+	if err != nil {
+		t.Fatalf("unexpected error encoding entry: %v", err)
+	}
+
+	t.Run("Test compression", func(t *testing.T) {
+		if data[2] != codecGzip {
+			t.Errorf("Expected codec %d, but got %d", codecGzip, data[2])
+		}
+		if len(data) >= len(entry.Value) {
+			t.Errorf("Expected encoded entry to be smaller than %d bytes, but got %d", len(entry.Value), len(data))
+		}
+
+		decoded, err := decodeEntry(data)
+		if err != nil {
+			t.Fatalf("unexpected error decoding entry: %v", err)
+		}
+		if !bytes.Equal(decoded.Value, entry.Value) {
+			t.Error("Expected decoded value to match the original")
+		}
+	})
+
+	t.Run("Test corruption", func(t *testing.T) {
+		corrupt := bytes.Clone(data)
+		corrupt[len(corrupt)/2] ^= 0xff
+
+		if _, err := decodeEntry(corrupt); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected error %v, but got %v", ErrCorrupt, err)
+		}
+		if _, err := decodeEntry(data[:entryHeaderSize-1]); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected error %v for a truncated entry, but got %v", ErrCorrupt, err)
+		}
+	})
+
+	t.Run("Test legacy entries", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+			t.Fatalf("unexpected error encoding entry: %v", err)
+		}
+
+		decoded, err := decodeEntry(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Expected a plain gob entry to decode, but got %v", err)
+		}
+		if !bytes.Equal(decoded.Value, entry.Value) {
+			t.Error("Expected decoded value to match the original")
+		}
+	})
+}
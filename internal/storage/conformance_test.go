@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+// testConformance runs the behaviour every persistent CacheStorage backend must share.
+func testConformance(t *testing.T, newCache func(t *testing.T) CacheStorage) {
+	ctx := context.Background()
+	newEntry := func(value string) CacheEntry {
+		return CacheEntry{
+			Value:          []byte(value),
+			ExpirationTime: time.Now().Add(1 * time.Hour).Round(0),
+			CreatedAt:      time.Now().Round(0),
+		}
+	}
+
+	t.Run("Test Get missing key", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		if _, err := cache.Get(ctx, "test-key"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error %v, but got %v", ErrNotFound, err)
+		}
+	})
+
+	t.Run("Test empty key", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		if _, err := cache.Get(ctx, ""); err == nil {
+			t.Error("Expected Get with an empty key to fail")
+		}
+		if err := cache.Set(ctx, "", newEntry("test-value")); err == nil {
+			t.Error("Expected Set with an empty key to fail")
+		}
+		if err := cache.Delete(ctx, ""); err == nil {
+			t.Error("Expected Delete with an empty key to fail")
+		}
+	})
+
+	t.Run("Test Set, Get and overwrite", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		entry := newEntry("test-value")
+		if err := cache.Set(ctx, "test-key", entry); err != nil {
+			t.Fatalf("Failed to set cache entry: %v", err)
+		}
+
+		got, err := cache.Get(ctx, "test-key")
+		if err != nil {
+			t.Fatalf("Failed to get cache entry: %v", err)
+		}
+		if string(got.Value) != "test-value" {
+			t.Errorf("Expected value test-value, but got %s", got.Value)
+		}
+		if !got.ExpirationTime.Equal(entry.ExpirationTime) {
+			t.Errorf("Expected expiration time %v, but got %v", entry.ExpirationTime, got.ExpirationTime)
+		}
+		if !got.CreatedAt.Equal(entry.CreatedAt) {
+			t.Errorf("Expected creation time %v, but got %v", entry.CreatedAt, got.CreatedAt)
+		}
+
+		if err := cache.Set(ctx, "test-key", newEntry("test-value-2")); err != nil {
+			t.Fatalf("Failed to overwrite cache entry: %v", err)
+		}
+		got, _ = cache.Get(ctx, "test-key")
+		if string(got.Value) != "test-value-2" {
+			t.Errorf("Expected overwritten value test-value-2, but got %s", got.Value)
+		}
+	})
+
+	t.Run("Test expired entries are returned", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		entry := newEntry("test-value")
+		entry.ExpirationTime = time.Now().Add(-1 * time.Hour).Round(0)
+		if err := cache.Set(ctx, "test-key", entry); err != nil {
+			t.Fatalf("Failed to set cache entry: %v", err)
+		}
+
+		got, err := cache.Get(ctx, "test-key")
+		if err != nil {
+			t.Fatalf("Expected the expired entry to be returned, but got %v", err)
+		}
+		if !got.ExpirationTime.Equal(entry.ExpirationTime) {
+			t.Errorf("Expected expiration time %v, but got %v", entry.ExpirationTime, got.ExpirationTime)
+		}
+
+		seen := 0
+		_ = cache.ForEach(ctx, func(key string, entry CacheEntry) error {
+			seen++
+			return nil
+		})
+		if seen != 1 {
+			t.Errorf("Expected ForEach to visit the expired entry, but it visited %d entries", seen)
+		}
+	})
+
+	t.Run("Test Delete", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		_ = cache.Set(ctx, "test-key", newEntry("test-value"))
+		if err := cache.Delete(ctx, "test-key"); err != nil {
+			t.Fatalf("Failed to delete cache entry: %v", err)
+		}
+		if _, err := cache.Get(ctx, "test-key"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error %v, but got %v", ErrNotFound, err)
+		}
+		if err := cache.Delete(ctx, "missing-key"); err != nil {
+			t.Errorf("Expected deleting a missing key to succeed, but got %v", err)
+		}
+	})
+
+	t.Run("Test ForEach", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		keys := []string{"result/b", "response/a", "result/a"}
+		for _, key := range keys {
+			_ = cache.Set(ctx, key, newEntry("value-"+key))
+		}
+
+		var seen []string
+		err := cache.ForEach(ctx, func(key string, entry CacheEntry) error {
+			if string(entry.Value) != "value-"+key {
+				t.Errorf("Expected value value-%s, but got %s", key, entry.Value)
+			}
+			seen = append(seen, key)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to iterate cache: %v", err)
+		}
+
+		sort.Strings(keys)
+		if strings.Join(seen, ",") != strings.Join(keys, ",") {
+			t.Errorf("Expected keys %v in order, but got %v", keys, seen)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err = cache.ForEach(ctx, func(key string, entry CacheEntry) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("Expected ForEach to stop at the first error, but got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("Test DeleteFunc", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		for _, key := range []string{"result/a", "result/b", "response/a"} {
+			_ = cache.Set(ctx, key, newEntry("test-value"))
+		}
+
+		deleted, err := cache.DeleteFunc(ctx, func(key string, entry CacheEntry) bool {
+			return strings.HasPrefix(key, "result/")
+		})
+		if err != nil {
+			t.Fatalf("Failed to delete entries: %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("Expected 2 deleted entries, but got %d", deleted)
+		}
+		if _, err := cache.Get(ctx, "response/a"); err != nil {
+			t.Errorf("Expected unmatched entry to be kept, but got %v", err)
+		}
+	})
+
+	t.Run("Test Clear", func(t *testing.T) {
+		cache := newCache(t)
+		defer cache.Close()
+
+		_ = cache.Set(ctx, "test-key-1", newEntry("test-value-1"))
+		_ = cache.Set(ctx, "test-key-2", newEntry("test-value-2"))
+		if err := cache.Clear(ctx); err != nil {
+			t.Fatalf("Failed to clear cache: %v", err)
+		}
+
+		stats, err := CollectStats(ctx, cache)
+		if err != nil {
+			t.Fatalf("Failed to collect stats: %v", err)
+		}
+		if stats.Entries != 0 {
+			t.Errorf("Expected an empty cache, but got %d entries", stats.Entries)
+		}
+
+		if err := cache.Set(ctx, "test-key-1", newEntry("test-value-1")); err != nil {
+			t.Errorf("Expected cache to be usable after Clear, but got %v", err)
+		}
+	})
+}
+
+func TestBoltConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) CacheStorage {
+		cache, err := newBoltCacheAt(path.Join(t.TempDir(), "cache.db"), config.Database{})
+		if err != nil {
+			t.Fatalf("Failed to create new bolt cache: %v", err)
+		}
+		return cache
+	})
+}
+
+func TestMemoryConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) CacheStorage {
+		cache, _ := NewMemoryCache(config.Database{})
+		return cache
+	})
+}
+
+func TestFSConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) CacheStorage {
+		cache, err := newFSCacheAt(path.Join(t.TempDir(), fsDirName), false)
+		if err != nil {
+			t.Fatalf("Failed to create new filesystem cache: %v", err)
+		}
+		return cache
+	})
+}
+
+func TestNoopCache(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewNoopCache(config.Database{})
+
+	if err := cache.Set(ctx, "test-key", CacheEntry{Value: []byte("test-value")}); err != nil {
+		t.Fatalf("Expected Set to succeed, but got %v", err)
+	}
+	if _, err := cache.Get(ctx, "test-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error %v, but got %v", ErrNotFound, err)
+	}
+
+	stats, err := CollectStats(ctx, cache)
+	if err != nil || stats.Entries != 0 {
+		t.Errorf("Expected no entries, but got %d (%v)", stats.Entries, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for _, backend := range []string{"", BackendBolt, BackendMemory, BackendFS, BackendNone} {
+		cache, err := Open(config.Database{Backend: backend})
+		if err != nil {
+			t.Errorf("Failed to open backend %q: %v", backend, err)
+			continue
+		}
+		cache.Close()
+	}
+
+	if _, err := Open(config.Database{Backend: "redis"}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JesterSe7en/porygo/config"
+	"go.etcd.io/bbolt"
+)
+
+// Eviction policies accepted in config.Database.Eviction
+const (
+	EvictionLRU = "lru" // evict the least recently accessed entries first
+	EvictionLFU = "lfu" // evict the least frequently accessed entries first
+)
+
+const (
+	// metaSize is the encoded length of entryMeta
+	metaSize = 24
+
+	// metaVersion is bumped whenever backfillMeta has more to fill in
+	metaVersion = 2
+
+	// Reads buffered before their access times are written without waiting for a Set
+	accessFlushSize = 1024
+)
+
+// Keys in the stats bucket
+var (
+	evictionsKey   = []byte("evictions")
+	metaVersionKey = []byte("meta_version")
+	usageKey       = []byte("usage")
+)
+
+// EvictionCounter is implemented by caches that evict entries to stay within a size limit.
+type EvictionCounter interface {
+	Evictions() (uint64, error)
+}
+
+// entryMeta tracks the size and usage of a cache entry for eviction.
+type entryMeta struct {
+	Size       uint64
+	AccessedAt int64 // unix nanoseconds
+	Hits       uint64
+}
+
+func (m entryMeta) encode() []byte {
+	buf := make([]byte, metaSize)
+	binary.BigEndian.PutUint64(buf[0:8], m.Size)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(m.AccessedAt))
+	binary.BigEndian.PutUint64(buf[16:24], m.Hits)
+	return buf
+}
+
+func decodeMeta(data []byte) (entryMeta, error) {
+	if len(data) != metaSize {
+		return entryMeta{}, fmt.Errorf("invalid metadata length %d", len(data))
+	}
+	return entryMeta{
+		Size:       binary.BigEndian.Uint64(data[0:8]),
+		AccessedAt: int64(binary.BigEndian.Uint64(data[8:16])),
+		Hits:       binary.BigEndian.Uint64(data[16:24]),
+	}, nil
+}
+
+// limited reports whether cfg bounds the size of the cache.
+func limited(cfg config.Database) bool {
+	return cfg.MaxSize > 0 || cfg.MaxEntries > 0
+}
+
+// usage is the running total of the entries tracked in the meta bucket, kept
+// so writes can tell whether the cache is over its limits without scanning it.
+type usage struct {
+	Size  uint64
+	Count uint64
+}
+
+func getUsage(tx *bbolt.Tx, buckets bucketNames) usage {
+	v := tx.Bucket(buckets.stats).Get(usageKey)
+	if len(v) != 16 {
+		return usage{}
+	}
+	return usage{Size: binary.BigEndian.Uint64(v[0:8]), Count: binary.BigEndian.Uint64(v[8:16])}
+}
+
+func putUsage(tx *bbolt.Tx, buckets bucketNames, u usage) error {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:8], u.Size)
+	binary.BigEndian.PutUint64(buf[8:16], u.Count)
+	return tx.Bucket(buckets.stats).Put(usageKey, buf)
+}
+
+// remove takes an entry of the given size out of the totals.
+func (u *usage) remove(size uint64) {
+	u.Size -= min(size, u.Size)
+	u.Count -= min(1, u.Count)
+}
+
+// over reports whether u exceeds the limits of cfg.
+func (u usage) over(cfg config.Database) bool {
+	return (cfg.MaxSize > 0 && u.Size > uint64(cfg.MaxSize)) ||
+		(cfg.MaxEntries > 0 && u.Count > uint64(cfg.MaxEntries))
+}
+
+// putMeta records a freshly written entry of the given size.
+func putMeta(tx *bbolt.Tx, buckets bucketNames, key []byte, size int) error {
+	bucket := tx.Bucket(buckets.meta)
+
+	u := getUsage(tx, buckets)
+	if old, err := decodeMeta(bucket.Get(key)); err == nil {
+		u.remove(old.Size)
+	}
+	u.Size += uint64(size)
+	u.Count++
+
+	meta := entryMeta{Size: uint64(size), AccessedAt: time.Now().UnixNano()}
+	if err := bucket.Put(key, meta.encode()); err != nil {
+		return err
+	}
+	return putUsage(tx, buckets, u)
+}
+
+// deleteMeta forgets the metadata of a removed entry.
+func deleteMeta(tx *bbolt.Tx, buckets bucketNames, key []byte) error {
+	bucket := tx.Bucket(buckets.meta)
+
+	old, err := decodeMeta(bucket.Get(key))
+	if err != nil {
+		return bucket.Delete(key)
+	}
+	if err := bucket.Delete(key); err != nil {
+		return err
+	}
+
+	u := getUsage(tx, buckets)
+	u.remove(old.Size)
+	return putUsage(tx, buckets, u)
+}
+
+// access is a buffered read of a single key.
+type access struct {
+	at   int64 // unix nanoseconds of the latest read
+	hits uint64
+}
+
+// accessLog buffers reads of a size-limited cache so Get only needs a read
+// transaction. The buffered accesses are written by the next Set, or in a
+// batch once enough of them pile up.
+type accessLog struct {
+	mu      sync.Mutex
+	pending map[string]access
+}
+
+// record buffers a read of key and reports whether the buffer should be flushed.
+func (l *accessLog) record(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending == nil {
+		l.pending = make(map[string]access)
+	}
+	a := l.pending[key]
+	a.at = time.Now().UnixNano()
+	a.hits++
+	l.pending[key] = a
+
+	return len(l.pending) >= accessFlushSize
+}
+
+// take empties the buffer and returns what it held.
+func (l *accessLog) take() map[string]access {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := l.pending
+	l.pending = nil
+	return pending
+}
+
+// touchMeta writes buffered accesses to the metadata of entries that still exist.
+func touchMeta(tx *bbolt.Tx, buckets bucketNames, accesses map[string]access) error {
+	bucket := tx.Bucket(buckets.meta)
+
+	for key, a := range accesses {
+		meta, err := decodeMeta(bucket.Get([]byte(key)))
+		if err != nil {
+			// Removed since it was read.
+			continue
+		}
+		meta.AccessedAt = max(meta.AccessedAt, a.at)
+		meta.Hits += a.hits
+		if err := bucket.Put([]byte(key), meta.encode()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillMeta creates metadata for entries written before access tracking
+// existed and the running totals for databases written before they were kept.
+func backfillMeta(tx *bbolt.Tx, buckets bucketNames) error {
+	stats := tx.Bucket(buckets.stats)
+	if v := stats.Get(metaVersionKey); len(v) == 1 && v[0] >= metaVersion {
+		return nil
+	}
+
+	meta := tx.Bucket(buckets.meta)
+	now := time.Now().UnixNano()
+	err := tx.Bucket(buckets.cache).ForEach(func(k, v []byte) error {
+		if meta.Get(k) != nil {
+			return nil
+		}
+		return meta.Put(k, entryMeta{Size: uint64(len(v)), AccessedAt: now}.encode())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to backfill cache metadata: %w", err)
+	}
+
+	var u usage
+	err = meta.ForEach(func(k, v []byte) error {
+		if m, err := decodeMeta(v); err == nil {
+			u.Size += m.Size
+			u.Count++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to total cache metadata: %w", err)
+	}
+	if err := putUsage(tx, buckets, u); err != nil {
+		return err
+	}
+
+	return stats.Put(metaVersionKey, []byte{metaVersion})
+}
+
+// evictionCandidate is an entry that may be evicted to make room.
+type evictionCandidate struct {
+	key  []byte
+	meta entryMeta
+}
+
+// evict removes entries according to cfg.Eviction until the cache fits within
+// its configured limits. The entry stored under keep is never evicted. The
+// entries are only scanned once the running totals are over a limit.
+func evict(tx *bbolt.Tx, buckets bucketNames, cfg config.Database, keep []byte) error {
+	u := getUsage(tx, buckets)
+	if !u.over(cfg) {
+		return nil
+	}
+
+	meta := tx.Bucket(buckets.meta)
+
+	var candidates []evictionCandidate
+	err := meta.ForEach(func(k, v []byte) error {
+		m, err := decodeMeta(v)
+		if err != nil {
+			return nil
+		}
+		if !bytes.Equal(k, keep) {
+			candidates = append(candidates, evictionCandidate{key: append([]byte(nil), k...), meta: m})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].meta, candidates[j].meta
+		if strings.EqualFold(cfg.Eviction, EvictionLFU) && a.Hits != b.Hits {
+			return a.Hits < b.Hits
+		}
+		return a.AccessedAt < b.AccessedAt
+	})
+
+	var evicted uint64
+	cache := tx.Bucket(buckets.cache)
+	for _, candidate := range candidates {
+		if !u.over(cfg) {
+			break
+		}
+		if err := cache.Delete(candidate.key); err != nil {
+			return fmt.Errorf("failed to evict key: %w", err)
+		}
+		if err := meta.Delete(candidate.key); err != nil {
+			return fmt.Errorf("failed to evict key metadata: %w", err)
+		}
+		u.remove(candidate.meta.Size)
+		evicted++
+	}
+
+	if err := putUsage(tx, buckets, u); err != nil {
+		return err
+	}
+	return addEvictions(tx, buckets, evicted)
+}
+
+// addEvictions increments the persisted eviction counter by n.
+func addEvictions(tx *bbolt.Tx, buckets bucketNames, n uint64) error {
+	stats := tx.Bucket(buckets.stats)
+
+	var current uint64
+	if v := stats.Get(evictionsKey); len(v) == 8 {
+		current = binary.BigEndian.Uint64(v)
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, current+n)
+	return stats.Put(evictionsKey, buf)
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+const (
+	// Directory below the cache location holding the filesystem backend
+	fsDirName = "entries"
+
+	// Directory below the cache location holding one fsDirName-like root per namespace
+	fsNamespacesDirName = "namespaces"
+
+	// Files stored in every entry directory
+	fsKeyFile   = "key"
+	fsEntryFile = "entry"
+
+	fsDirMode = 0o750
+)
+
+func init() {
+	Register(BackendFS, NewFSCache)
+}
+
+// fsCache stores every entry in its own directory, addressed by the SHA-256 of
+// its key and sharded by the first byte of the hash. Each directory holds the
+// original key and the encoded entry, so the cache can be inspected and synced
+// with ordinary file tools.
+type fsCache struct {
+	root     string
+	readOnly bool
+}
+
+// NewFSCache opens the filesystem cache inside the configured cache directory.
+// Namespaces other than the default get a root of their own.
+func NewFSCache(cfg config.Database) (CacheStorage, error) {
+	dir, err := getCacheDir(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cache location: %w", err)
+	}
+
+	root := filepath.Join(dir, fsDirName)
+	if cfg.Namespace != "" {
+		root = filepath.Join(dir, fsNamespacesDirName, cfg.Namespace)
+	}
+	return newFSCacheAt(root, cfg.ReadOnly)
+}
+
+func newFSCacheAt(root string, readOnly bool) (CacheStorage, error) {
+	if readOnly {
+		if _, err := os.Stat(root); err != nil {
+			return nil, fmt.Errorf("cannot open cache read-only: %w", err)
+		}
+	} else if err := os.MkdirAll(root, fsDirMode); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &fsCache{root: root, readOnly: readOnly}, nil
+}
+
+// entryDir returns the directory holding key.
+func (f *fsCache) entryDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(f.root, name[:2], name)
+}
+
+// Get retrieves a cache entry by key.
+// Returns ErrNotFound if the key doesn't exist.
+func (f *fsCache) Get(ctx context.Context, key string) (CacheEntry, error) {
+	if len(key) == 0 {
+		return CacheEntry{}, errors.New("key cannot be empty")
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.entryDir(key), fsEntryFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, ErrNotFound
+	}
+	if err != nil {
+		return CacheEntry{}, fmt.Errorf("failed to read entry: %w", err)
+	}
+
+	entry, err := decodeEntry(data)
+	if err != nil {
+		return CacheEntry{}, fmt.Errorf("failed to decode entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Set stores entry under key, replacing the entry file atomically.
+func (f *fsCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	if len(key) == 0 {
+		return errors.New("key cannot be empty")
+	}
+
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	encodedEntry, err := encodeEntry(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+
+	dir := f.entryDir(key)
+	if err := os.MkdirAll(dir, fsDirMode); err != nil {
+		return fmt.Errorf("failed to create entry directory: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, fsKeyFile), []byte(key)); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, fsEntryFile), encodedEntry)
+}
+
+// Delete removes a key from the cache.
+func (f *fsCache) Delete(ctx context.Context, key string) error {
+	if len(key) == 0 {
+		return errors.New("key cannot be empty")
+	}
+
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := os.RemoveAll(f.entryDir(key)); err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+
+	return nil
+}
+
+// ForEach calls fn for every entry in the cache in key order.
+func (f *fsCache) ForEach(ctx context.Context, fn func(key string, entry CacheEntry) error) error {
+	dirs, err := f.entryDirs()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(dirs))
+	byKey := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		key, err := os.ReadFile(filepath.Join(dir, fsKeyFile))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, string(key))
+		byKey[string(key)] = dir
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(filepath.Join(byKey[key], fsEntryFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read entry %s: %w", key, err)
+		}
+
+		entry, err := decodeEntry(data)
+		if errors.Is(err, ErrCorrupt) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode entry %s: %w", key, err)
+		}
+
+		if err := fn(key, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteFunc removes every entry for which match returns true and reports how many were removed.
+func (f *fsCache) DeleteFunc(ctx context.Context, match func(key string, entry CacheEntry) bool) (int, error) {
+	if f.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	var keys []string
+	err := f.ForEach(ctx, func(key string, entry CacheEntry) error {
+		if match(key, entry) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, key := range keys {
+		if err := f.Delete(ctx, key); err != nil {
+			return i, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// Clear removes all entries from the cache.
+func (f *fsCache) Clear(ctx context.Context) error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := os.RemoveAll(f.root); err != nil {
+		return fmt.Errorf("failed to remove cache directory: %w", err)
+	}
+
+	if err := os.MkdirAll(f.root, fsDirMode); err != nil {
+		return fmt.Errorf("failed to recreate cache directory: %w", err)
+	}
+
+	return nil
+}
+
+// Verify decodes every entry below the root. Entry directories without a
+// readable key are reported under their path relative to the root.
+func (f *fsCache) Verify(ctx context.Context, repair bool) (VerifyReport, error) {
+	if repair && f.readOnly {
+		return VerifyReport{}, ErrReadOnly
+	}
+
+	dirs, err := f.entryDirs()
+	if err != nil {
+		return VerifyReport{}, err
+	}
+
+	report := VerifyReport{Corrupt: []CorruptEntry{}}
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		report.Checked++
+		key, reason := f.verifyDir(dir)
+		if reason == "" {
+			continue
+		}
+
+		report.Corrupt = append(report.Corrupt, CorruptEntry{Key: key, Reason: reason})
+		if repair {
+			if err := os.RemoveAll(dir); err != nil {
+				return report, fmt.Errorf("failed to delete entry %s: %w", key, err)
+			}
+			report.Removed++
+		}
+	}
+
+	sort.Slice(report.Corrupt, func(i, j int) bool {
+		return report.Corrupt[i].Key < report.Corrupt[j].Key
+	})
+
+	return report, nil
+}
+
+// verifyDir returns the key stored in dir and, if the entry is unreadable, why.
+func (f *fsCache) verifyDir(dir string) (string, string) {
+	key, err := os.ReadFile(filepath.Join(dir, fsKeyFile))
+	if err != nil {
+		rel, _ := filepath.Rel(f.root, dir)
+		return rel, fmt.Sprintf("failed to read key: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, fsEntryFile))
+	if err != nil {
+		return string(key), fmt.Sprintf("failed to read entry: %v", err)
+	}
+
+	if _, err := decodeEntry(data); err != nil {
+		return string(key), err.Error()
+	}
+
+	return string(key), ""
+}
+
+// Close does nothing; every operation works directly on the filesystem.
+func (f *fsCache) Close() error {
+	return nil
+}
+
+// entryDirs lists every entry directory below the root.
+func (f *fsCache) entryDirs() ([]string, error) {
+	shards, err := os.ReadDir(f.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var dirs []string
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(filepath.Join(f.root, shard.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				dirs = append(dirs, filepath.Join(f.root, shard.Name(), entry.Name()))
+			}
+		}
+	}
+
+	return dirs, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place so
+// readers never observe a partially written file.
+func writeFileAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(name), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(name), err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(name), err)
+	}
+
+	return nil
+}
@@ -5,12 +5,15 @@ package storage
 
 import (
 	"sync"
+
+	"github.com/JesterSe7en/porygo/config"
 )
 
 // CacheManager manages a single shared cache instance across the application.
 // It ensures thread safety and guarantees only one cache instance exists.
 type CacheManager struct {
 	cache CacheStorage
+	cfg   config.Database
 	mu    sync.RWMutex
 }
 
@@ -27,6 +30,15 @@ func GetCacheManager() *CacheManager {
 	return manager
 }
 
+// Configure sets the database configuration used when the cache is opened.
+// It has no effect on a cache that is already open.
+func (m *CacheManager) Configure(cfg config.Database) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cfg = cfg
+}
+
 // GetCache returns the shared cache instance, creating it if needed.
 // Thread-safe and ensures only one cache instance exists.
 func (m *CacheManager) GetCache() (CacheStorage, error) {
@@ -46,7 +58,7 @@ func (m *CacheManager) GetCache() (CacheStorage, error) {
 		return m.cache, nil
 	}
 
-	cache, err := NewBoltCache()
+	cache, err := Open(m.cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,130 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func init() {
+	Register(BackendMemory, NewMemoryCache)
+}
+
+// memoryCache is a map that keeps entries for the lifetime of the process.
+// Like the other backends it returns expired entries and leaves removing them
+// to its callers, who may still revalidate or serve them stale.
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache returns an empty in-memory cache.
+func NewMemoryCache(cfg config.Database) (CacheStorage, error) {
+	return &memoryCache{entries: make(map[string]CacheEntry)}, nil
+}
+
+// Get retrieves a cache entry by key.
+// Returns ErrNotFound if the key doesn't exist.
+func (m *memoryCache) Get(ctx context.Context, key string) (CacheEntry, error) {
+	if len(key) == 0 {
+		return CacheEntry{}, errors.New("key cannot be empty")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return CacheEntry{}, ErrNotFound
+	}
+
+	return entry, nil
+}
+
+// Set stores a copy of entry under key.
+func (m *memoryCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	if len(key) == 0 {
+		return errors.New("key cannot be empty")
+	}
+
+	entry.Value = append([]byte(nil), entry.Value...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry
+	return nil
+}
+
+// Delete removes a key from the cache.
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	if len(key) == 0 {
+		return errors.New("key cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// ForEach calls fn for every entry in the cache in key order.
+func (m *memoryCache) ForEach(ctx context.Context, fn func(key string, entry CacheEntry) error) error {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.entries))
+	snapshot := make(map[string]CacheEntry, len(m.entries))
+	for key, entry := range m.entries {
+		keys = append(keys, key)
+		snapshot[key] = entry
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(key, snapshot[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteFunc removes every entry for which match returns true and reports how many were removed.
+func (m *memoryCache) DeleteFunc(ctx context.Context, match func(key string, entry CacheEntry) bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for key, entry := range m.entries {
+		if match(key, entry) {
+			delete(m.entries, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Clear removes all entries from the cache.
+func (m *memoryCache) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]CacheEntry)
+	return nil
+}
+
+// Close releases the stored entries.
+func (m *memoryCache) Close() error {
+	return m.Clear(context.Background())
+}
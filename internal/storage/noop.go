@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+func init() {
+	Register(BackendNone, NewNoopCache)
+}
+
+// noopCache discards everything written to it, disabling caching entirely.
+type noopCache struct{}
+
+// NewNoopCache returns a cache that never stores anything.
+func NewNoopCache(cfg config.Database) (CacheStorage, error) {
+	return noopCache{}, nil
+}
+
+// Get always reports a miss.
+func (noopCache) Get(ctx context.Context, key string) (CacheEntry, error) {
+	return CacheEntry{}, ErrNotFound
+}
+
+// Set discards the entry.
+func (noopCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	return nil
+}
+
+// Delete does nothing.
+func (noopCache) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+// ForEach never calls fn.
+func (noopCache) ForEach(ctx context.Context, fn func(key string, entry CacheEntry) error) error {
+	return nil
+}
+
+// DeleteFunc never removes anything.
+func (noopCache) DeleteFunc(ctx context.Context, match func(key string, entry CacheEntry) bool) (int, error) {
+	return 0, nil
+}
+
+// Clear does nothing.
+func (noopCache) Clear(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing.
+func (noopCache) Close() error {
+	return nil
+}
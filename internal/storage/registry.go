@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JesterSe7en/porygo/config"
+)
+
+// Names of the built-in cache backends, selected with database.backend
+const (
+	BackendBolt   = "bolt"   // single BoltDB file (default)
+	BackendMemory = "memory" // in-memory map that lives for a single run
+	BackendFS     = "fs"     // one directory per entry on the filesystem
+	BackendNone   = "none"   // caching disabled
+)
+
+// Factory opens a cache backend configured by cfg.
+type Factory func(cfg config.Database) (CacheStorage, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a cache backend available under name.
+// It panics if a backend is registered twice under the same name.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("storage: backend %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Backends returns the names of all registered backends in sorted order.
+func Backends() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the backend selected by cfg.Backend, falling back to BoltDB when unset.
+func Open(cfg config.Database) (CacheStorage, error) {
+	name := strings.ToLower(cfg.Backend)
+	if name == "" {
+		name = BackendBolt
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown cache backend %q, expected one of %s", cfg.Backend, strings.Join(Backends(), ", "))
+	}
+
+	return factory(cfg)
+}
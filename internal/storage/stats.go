@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Stats summarizes the contents of a cache.
+type Stats struct {
+	Entries    int    `json:"entries"`
+	Expired    int    `json:"expired"`
+	TotalBytes int64  `json:"total_bytes"`
+	FileSize   int64  `json:"file_size,omitempty"`
+	Evictions  uint64 `json:"evictions"`
+}
+
+// FileSizer is implemented by caches that are backed by a single file on disk.
+type FileSizer interface {
+	FileSize() (int64, error)
+}
+
+// CollectStats walks every entry in cache and summarizes it.
+func CollectStats(ctx context.Context, cache CacheStorage) (Stats, error) {
+	var stats Stats
+	now := time.Now()
+
+	err := cache.ForEach(ctx, func(key string, entry CacheEntry) error {
+		stats.Entries++
+		stats.TotalBytes += int64(len(entry.Value))
+		if now.After(entry.ExpirationTime) {
+			stats.Expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	if sizer, ok := cache.(FileSizer); ok {
+		size, err := sizer.FileSize()
+		if err != nil {
+			return Stats{}, err
+		}
+		stats.FileSize = size
+	}
+
+	if counter, ok := cache.(EvictionCounter); ok {
+		evictions, err := counter.Evictions()
+		if err != nil {
+			return Stats{}, err
+		}
+		stats.Evictions = evictions
+	}
+
+	return stats, nil
+}
@@ -9,6 +9,7 @@ import (
 type CacheEntry struct {
 	Value          []byte
 	ExpirationTime time.Time
+	CreatedAt      time.Time
 }
 
 type CacheStorage interface {
@@ -16,5 +17,15 @@ type CacheStorage interface {
 	Set(ctx context.Context, key string, value CacheEntry) error
 	Delete(ctx context.Context, key string) error
 	Clear(ctx context.Context) error
+	// ForEach calls fn for every entry in the cache, stopping at the first error.
+	// Corrupt entries are skipped; a Verifier reports them. fn must not modify the cache.
+	ForEach(ctx context.Context, fn func(key string, entry CacheEntry) error) error
+	// DeleteFunc removes every entry for which match returns true and reports how many were removed.
+	DeleteFunc(ctx context.Context, match func(key string, entry CacheEntry) bool) (int, error)
 	Close() error
 }
+
+// Compactor is implemented by caches that can reclaim the disk space left behind by deleted entries.
+type Compactor interface {
+	Compact(ctx context.Context) error
+}
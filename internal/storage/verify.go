@@ -0,0 +1,26 @@
+// Copyright (c) 2025 Alexander Chan
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import "context"
+
+// CorruptEntry is a stored entry that failed its integrity check.
+type CorruptEntry struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// VerifyReport summarizes an integrity scan of a cache.
+type VerifyReport struct {
+	Checked  int            `json:"checked"`            // entries read
+	Corrupt  []CorruptEntry `json:"corrupt"`            // entries that could not be decoded
+	Removed  int            `json:"removed"`            // corrupt entries deleted by a repair
+	Problems []string       `json:"problems,omitempty"` // structural problems in the underlying store
+}
+
+// Verifier is implemented by caches that store encoded entries and can check them for corruption.
+// With repair set, corrupt entries are deleted.
+type Verifier interface {
+	Verify(ctx context.Context, repair bool) (VerifyReport, error)
+}
@@ -24,6 +24,11 @@ func New(workerCount int, bufferSize int) *WorkerPool {
 // Submit adds a job to the pool, but will not block indefinitely.
 // It returns an error if the context is canceled before the job can be submitted.
 func (wp *WorkerPool) Submit(ctx context.Context, job Job) error {
+	// select picks at random when the queue has room too, so check first.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()